@@ -0,0 +1,224 @@
+package mango
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Asset modes (from config "Assets: ...")
+// Move	-- move files to PublicPath/images or PublicPath/data (default)
+// Keep	-- leave files in ContentPath and serve them from there
+// Copy	-- copy files to PublicPath/assets keeping content structure
+const (
+	assetsMove = "Move"
+	assetsKeep = "Keep"
+	assetsCopy = "Copy"
+)
+
+// Url scope for assets by content-relative path
+// /{File} --> /assets/1_en/news/logo.png
+const assetsScope = "assets"
+
+// Is given file name an asset (not page, not config file)
+func isAssetName(fname string) bool {
+	return fname != "" && fname[0] != '.' && strings.ToLower(filepath.Ext(fname)) != _Md
+}
+
+// Index all assets before pages are loaded
+// so page content can link to any of them
+// Only for Keep and Copy modes (moved files are not indexed)
+func (app *Application) loadAssets(s *Snapshot) {
+	if app.assetMode != assetsKeep && app.assetMode != assetsCopy {
+		return
+	}
+
+	filepath.Walk(app.ContentPath, func(fpath string, finfo os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		fname := finfo.Name()
+		if finfo.IsDir() {
+			// Hidden directories are not loaded as pages too
+			if fname[0] == '.' && fpath != app.ContentPath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isAssetName(fname) {
+			app.addAsset(s, fpath)
+		}
+		return nil
+	})
+}
+
+// Add one asset to snapshot index (and copy if needed)
+func (app *Application) addAsset(s *Snapshot, fpath string) {
+	rpath, err := filepath.Rel(app.ContentPath, fpath)
+	if err != nil || strings.HasPrefix(rpath, "..") {
+		return
+	}
+	rpath = filepath.ToSlash(rpath)
+
+	s.assets[rpath] = fpath
+	fname := filepath.Base(fpath)
+	s.assetNames[fname] = append(s.assetNames[fname], rpath)
+	sort.Strings(s.assetNames[fname])
+
+	dst := app.PublicPath + "/" + assetsScope + "/" + rpath
+	if app.assetMode == assetsCopy && !s.isDryRun && !isFresh(fpath, dst) {
+		if err := copyFile(fpath, dst); err != nil {
+			s.diagnostics.Add(fpath, 0, SeverityError, "can't copy file to public path: %v", err)
+		}
+	}
+
+	// Variants are in public path
+	// next to where asset is (or would be)
+	app.processImage(s, fpath, dst)
+}
+
+// Remove one asset from snapshot index
+func (app *Application) removeAsset(s *Snapshot, fpath string) {
+	rpath, err := filepath.Rel(app.ContentPath, fpath)
+	if err != nil {
+		return
+	}
+	rpath = filepath.ToSlash(rpath)
+
+	delete(s.assets, rpath)
+	fname := filepath.Base(fpath)
+	var rpaths []string
+	for _, rpath2 := range s.assetNames[fname] {
+		if rpath2 != rpath {
+			rpaths = append(rpaths, rpath2)
+		}
+	}
+	s.assetNames[fname] = rpaths
+}
+
+// Move not page file (image, pdf..) to public path
+// All files end up in one directory, so same names overwrites each other
+func (app *Application) loadAsset(s *Snapshot, fpath, fname string) {
+	if app.assetMode != assetsMove && app.assetMode != "" {
+		// Already indexed by loadAssets
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(fname))
+	images := ".png, .gif, .jpg, .jpeg, .svg," // comma-ended
+	scope := "images"
+	if strings.Index(images, ext) == -1 {
+		// Images move to /public/data/
+		scope = "data"
+	}
+	mvPath := app.PublicPath + "/" + scope + "/" + fname
+
+	// Detect same names from different directories
+	if prev, ok := s.assets[scope+"/"+fname]; ok {
+		s.diagnostics.Add(fpath+"/"+fname, 0, SeverityWarning, "asset %q overwrites %q in public path", fname, prev)
+	}
+	s.assets[scope+"/"+fname] = fpath + "/" + fname
+
+	if s.isDryRun {
+		return
+	}
+
+	if err := os.Rename(fpath+"/"+fname, mvPath); err != nil {
+		s.diagnostics.Add(fpath+"/"+fname, 0, SeverityError, "can't move file to public path: %v", err)
+		return
+	}
+
+	app.processImage(s, mvPath, mvPath)
+}
+
+// Asset file (path relative to FileURL) for link in page content
+// Looks relative to page directory, then relative to ContentPath,
+// then by file name (images/logo.png as in Move mode)
+// Returns "" if asset not found
+func (s *Snapshot) assetFile(page *Page, ref string) string {
+	if ref == "" || strings.Index(ref, ":") >= 0 || len(s.assetNames) == 0 {
+		return ""
+	}
+	ref = strings.SplitN(ref, "?", 2)[0]
+	app := page.App
+
+	// Relative to page
+	if ref[0] != '/' && page.IsSet("Path") {
+		dir := page.Get("Path")
+		if !page.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+		if rpath, err := filepath.Rel(app.ContentPath, filepath.Join(dir, ref)); err == nil {
+			if _, ok := s.assets[filepath.ToSlash(rpath)]; ok {
+				return assetsScope + "/" + filepath.ToSlash(rpath)
+			}
+		}
+	}
+
+	// Relative to ContentPath
+	rpath := strings.TrimPrefix(ref, "/")
+	rpath = strings.TrimPrefix(rpath, assetsScope+"/")
+	if _, ok := s.assets[rpath]; ok {
+		return assetsScope + "/" + rpath
+	}
+
+	// By file name
+	// images/logo.png, /data/file.pdf, logo.png
+	for _, scope := range []string{"images/", "data/"} {
+		rpath = strings.TrimPrefix(rpath, scope)
+	}
+	if strings.Index(rpath, "/") >= 0 {
+		return ""
+	}
+	rpaths := s.assetNames[rpath]
+	if len(rpaths) == 0 {
+		return ""
+	}
+	if len(rpaths) > 1 {
+		s.diagnostics.Add(page.Get("Path"), 0, SeverityWarning, "asset name %q is ambiguous (%s), using %q", rpath, strings.Join(rpaths, ", "), rpaths[0])
+	}
+	return assetsScope + "/" + rpaths[0]
+}
+
+// Content file by asset path relative to FileURL
+// Returns "" if not an asset
+func (s *Snapshot) assetPath(urlPath string) string {
+	rpath := strings.TrimPrefix(urlPath, "/")
+	if !strings.HasPrefix(rpath, assetsScope+"/") {
+		return ""
+	}
+	return s.assets[strings.TrimPrefix(rpath, assetsScope+"/")]
+}
+
+// Files from PublicPath
+// and assets from ContentPath if they are kept there
+func (srv *Server) fileServer() http.Handler {
+	fs := http.FileServer(http.Dir(srv.App.PublicPath))
+	if srv.App.assetMode != assetsKeep {
+		return fs
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fpath := srv.App.Snapshot().assetPath(r.URL.Path)
+		if fpath == "" {
+			fs.ServeHTTP(w, r)
+			return
+		}
+
+		// Content files are not changed, so EXIF is removed on the fly
+		if srv.App.isStripEXIF && isJPEG(fpath) {
+			if finfo, err := os.Stat(fpath); err == nil {
+				if buf, err := ioutil.ReadFile(fpath); err == nil {
+					http.ServeContent(w, r, finfo.Name(), finfo.ModTime(), bytes.NewReader(stripEXIF(buf)))
+					return
+				}
+			}
+		}
+
+		http.ServeFile(w, r, fpath)
+	})
+}
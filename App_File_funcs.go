@@ -0,0 +1,172 @@
+package mango
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Placeholders in FileURL template (with or without route regexp)
+// /static/{Hash:[^/]+}/{File:.+}
+var (
+	reFilePlaceholder = regexp.MustCompile(`\{File(:[^}]*)?\}`)
+	reHashPlaceholder = regexp.MustCompile(`\{Hash(:[^}]*)?\}`)
+)
+
+// Length of content hash in file urls
+const fileHashLen = 12
+
+// Hash used in urls of missing files
+const noFileHash = "0"
+
+// Cached content hash of one file
+// Valid while file size and modification time are the same
+type fileHash struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// Content hashes of public files by absolute path
+// Calculated on first use
+type fileHashes struct {
+	sync.RWMutex
+	m map[string]fileHash
+}
+
+// FileURL - url of file by path relative to PublicPath
+// {Hash} in FileURL template is replaced with file content hash
+// /static/{Hash}/{File}	--> /static/1a2b3c4d5e6f/css/style.css
+// /{File}?v={Hash}		--> /css/style.css?v=1a2b3c4d5e6f
+func (app *Application) FileURL(rpath string) string {
+	return app.fileURL(app.Snapshot(), rpath)
+}
+
+// FileHash - content hash of file by path relative to PublicPath
+// Returns "" if file not found
+func (app *Application) FileHash(rpath string) string {
+	return app.fileHash(app.Snapshot(), rpath)
+}
+
+// File url using given snapshot to find assets kept in content
+func (app *Application) fileURL(s *Snapshot, rpath string) string {
+	rpath = strings.TrimPrefix(rpath, "/")
+	url := reFilePlaceholder.ReplaceAllLiteralString(app.URLTemplates["File"], rpath)
+
+	if reHashPlaceholder.MatchString(url) {
+		hash := app.fileHash(s, rpath)
+		if hash == "" {
+			hash = noFileHash
+		}
+		url = reHashPlaceholder.ReplaceAllLiteralString(url, hash)
+	}
+
+	return url
+}
+
+// Is FileURL template fingerprinted with {Hash}
+func (app *Application) isFileHash() bool {
+	return reHashPlaceholder.MatchString(app.URLTemplates["File"])
+}
+
+// File content hash using given snapshot to find assets kept in content
+func (app *Application) fileHash(s *Snapshot, rpath string) string {
+	fpath := app.publicFile(s, strings.TrimPrefix(rpath, "/"))
+	if fpath == "" {
+		return ""
+	}
+	finfo, err := os.Stat(fpath)
+	if err != nil || finfo.IsDir() {
+		return ""
+	}
+
+	app.fileHashes.RLock()
+	cached, ok := app.fileHashes.m[fpath]
+	app.fileHashes.RUnlock()
+	if ok && cached.size == finfo.Size() && cached.modTime.Equal(finfo.ModTime()) {
+		return cached.hash
+	}
+
+	f, err := os.Open(fpath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	hash := hex.EncodeToString(h.Sum(nil))[:fileHashLen]
+
+	app.fileHashes.Lock()
+	app.fileHashes.m[fpath] = fileHash{
+		size:    finfo.Size(),
+		modTime: finfo.ModTime(),
+		hash:    hash,
+	}
+	app.fileHashes.Unlock()
+
+	return hash
+}
+
+// Public file by path relative to FileURL root
+// File in PublicPath or asset kept in content
+func (app *Application) publicFile(s *Snapshot, rpath string) string {
+	fpath := app.PublicPath + "/" + rpath
+	if _, err := os.Stat(fpath); err == nil {
+		return fpath
+	}
+	if s != nil {
+		return s.assetPath(rpath)
+	}
+	return ""
+}
+
+// Handler for FileURL route
+// Serves file by {File} and if url has current {Hash}
+// tells browsers to cache it forever (new content - new url)
+func (srv *Server) fileRoute(fs http.Handler) http.Handler {
+	// Hash can be in query: /{File}?v={Hash}
+	hashKey := ""
+	if arr := strings.SplitN(srv.App.URLTemplates["File"], "?", 2); len(arr) == 2 {
+		if query, err := url.ParseQuery(arr[1]); err == nil {
+			for key := range query {
+				if reHashPlaceholder.MatchString(query.Get(key)) {
+					hashKey = key
+				}
+			}
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		file := vars["File"]
+
+		hash := vars["Hash"]
+		if hashKey != "" {
+			hash = r.URL.Query().Get(hashKey)
+		}
+		if hash != "" && hash == srv.App.fileHash(srv.App.Snapshot(), file) {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+
+		// File server sees only file path
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = "/" + file
+		r2.URL.RawPath = ""
+		fs.ServeHTTP(w, r2)
+	})
+}
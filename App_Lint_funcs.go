@@ -0,0 +1,73 @@
+package mango
+
+import (
+	"os"
+	"strings"
+)
+
+// Lint - load content again without side effects and return all problems
+// Files are not moved to public path, sitemap is not written
+// and currently loaded content stays as is.
+// Besides load problems (dates, duplicate slugs, ContentFrom..)
+// checks also Redirect targets and not collected collection keys
+func (app *Application) Lint() []Diagnostic {
+	s := app.loadSnapshot(true)
+
+	app.lintRedirects(s)
+	app.lintCollectionKeys(s)
+
+	return s.Diagnostics()
+}
+
+// Redirect must point to existing page, language, public file
+// or other site (absolute url)
+func (app *Application) lintRedirects(s *Snapshot) {
+	// Every local url that can be opened
+	urls := make(map[string]bool, 0)
+	for _, p := range s.Pages {
+		lang := p.Get("Slug")
+		urls["/"+lang] = true
+		urls["/"+lang+"/"] = true
+	}
+	s.slugPages.Filter(func(p *Page) bool {
+		if !p.IsSet("Redirect") {
+			urls[p.Get("URL")] = true
+		}
+		return false
+	})
+
+	for _, p := range s.slugPages.Filter(func(p *Page) bool { return p.IsSet("Redirect") }) {
+		url := p.Get("Redirect")
+		if url[0] != '/' || urls[url] {
+			// Other site, query or found page
+			continue
+		}
+
+		// File from public path
+		if _, err := os.Stat(app.PublicPath + strings.SplitN(url, "?", 2)[0]); err == nil {
+			continue
+		}
+
+		s.diagnostics.addParam(p.Get("Path"), "Redirect", SeverityWarning, "Redirect target %q not found", url)
+	}
+}
+
+// Param looks like collection but is not collected
+// "Tag: dog" when only "Tags" are collected
+func (app *Application) lintCollectionKeys(s *Snapshot) {
+	s.slugPages.Filter(func(p *Page) bool {
+		for _, key := range p.Split("SourceParams", ",") {
+			if _, ok := s.collections[key]; ok {
+				continue
+			}
+			if c := s.Collection(key); c != nil {
+				for ckey := range s.collections {
+					if s.collections[ckey] == c {
+						s.diagnostics.addParam(p.Get("Path"), key, SeverityWarning, "unknown collection key %q (collected is %q)", key, ckey)
+					}
+				}
+			}
+		}
+		return false
+	})
+}
@@ -1,49 +1,48 @@
 package mango
 
-import (
-	"os"
-	"strings"
-)
+import "strings"
 
 // NewPage already linked to app
 func (app *Application) NewPage(lang, label string) *Page {
 	page := newPage(label)
 
-	// Reload all conent for app if "".reload" file is created in bin path
-	reloadFpath := app.BinPath() + "/.reload"
-	if _, err := os.Stat(reloadFpath); err == nil {
-		// log.Println("[.reload] Reload all pages")
-		os.Remove(reloadFpath)
-		app.LoadContent() // Reload
-		// time.Sleep(time.Second * 1)
-		// time.Sleep(time.Millisecond * 1)
-	}
-
 	// Set initial language (mandatory)
 	page.SetLang(lang)
 
 	// Link page to app
-	app.linkPage(page)
+	app.linkPage(app.Snapshot(), page)
 	return page
 }
 
 // FileToPage for application
 func (app *Application) FileToPage(fpath string) *Page {
-	page := fileToPage(fpath)
-	app.linkPage(page)
+	page := fileToPage(app, fpath, nil)
+	app.linkPage(app.Snapshot(), page)
+	return page
+}
+
+// Create page from file and link it to given snapshot
+// Problems are reported to snapshot diagnostics
+func (app *Application) filePage(s *Snapshot, fpath string) *Page {
+	page := fileToPage(app, fpath, s.diagnostics)
+	app.linkPage(s, page)
 	return page
 }
 
 // Page - get one page by given slug.
 // Slug must be equal and is case-sensitive
 func (app *Application) Page(slug string) *Page {
-	return app.slugPages.Get(slug)
+	return app.Snapshot().Page(slug)
 }
 
 // Assign page to application
 // and add some app related params
-func (app *Application) linkPage(page *Page) {
+// Page looks up other pages in given snapshot
+func (app *Application) linkPage(s *Snapshot, page *Page) {
+	page.Lock()
 	page.App = app
+	page.snap = s
+	page.Unlock()
 
 	// Add more params from absolute path
 	page.setPathParams()
@@ -53,7 +52,7 @@ func (app *Application) linkPage(page *Page) {
 	page.SetLang(page.Get("Lang"))
 
 	// Load page defaults from language root page
-	if pDef := app.Page("." + page.Get("Lang") + "-defaults"); pDef != nil {
+	if pDef := s.Page("." + page.Get("Lang") + "-defaults"); pDef != nil {
 		page.MergeParams(pDef.params) // fill empty params with defaults
 		// After merge check Title
 		// Title is not merged of it's special status
@@ -63,17 +62,22 @@ func (app *Application) linkPage(page *Page) {
 		}
 	}
 
+	// Operators with nothing left to merge (+Key, Key?, -Key, !Key)
+	// Defaults pages keep them for pages that inherit
+	if !strings.HasPrefix(page.Get("FileName"), ".") {
+		page.Lock()
+		resolveParamOps(page.params)
+		page.Unlock()
+	}
+
 	// Add "URL" param
 	if page.ParamsLen() > 0 {
 		// Only if all other params is set
-		url := app.URLTemplates["Page"]
-		url = page.PopulateParams(url)
-		url = "/" + strings.TrimLeft(url, "/") // Fix broken url "//slug/" to "/slug"
-		page.Set("URL", url)
+		page.Set("URL", app.pageURL(page))
 	}
 
 	// Add to collections
-	for ckey := range app.collections {
+	for ckey, c := range s.collections {
 		// Is page have such collection key
 		if page.IsSet(ckey) {
 			// Get this page valuesfrom from c.key
@@ -81,18 +85,26 @@ func (app *Application) linkPage(page *Page) {
 
 			// Every [value: *Page] added to collection by c.key
 			for _, itemKey := range arr {
-				// Add to app.collections[ckey][itemKey]-> [page1, page2, ..]
-				app.collections[ckey].Append(itemKey, page)
+				// Add to s.collections[ckey][itemKey]-> [page1, page2, ..]
+				c.Append(itemKey, page)
 			}
 		}
 	}
 
 	// Reset content because that function holds content normalizer
 	// adds correct image and data urls
-	page.SetContent(page.Content())
+	page.SetContent(page.rawContent())
+}
+
+// Construct page url from "Page" url template
+func (app *Application) pageURL(page *Page) string {
+	url := app.URLTemplates["Page"]
+	url = page.PopulateParams(url)
+	url = "/" + strings.TrimLeft(url, "/") // Fix broken url "//slug/" to "/slug"
+	return url
 }
 
 // PageCount - total count of pages
 func (app *Application) PageCount() int {
-	return app.slugPages.Len()
+	return app.Snapshot().PageCount()
 }
@@ -0,0 +1,333 @@
+package mango
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// More changed paths than this are reloaded as whole content
+const maxReloadPaths = 100
+
+// ReloadPath - reload only given files or directories (with sub-pages)
+// instead of whole content. Given paths can be already removed.
+// Falls back to LoadContent if change affects all pages
+// (language folders and their .defaults)
+// Changes are made in copy of current snapshot (with copies of pages)
+// and swapped in at once. Previous snapshot is never changed
+func (app *Application) ReloadPath(fpaths ...string) {
+	if len(fpaths) == 0 || len(fpaths) > maxReloadPaths {
+		app.LoadContent()
+		return
+	}
+
+	app.chBusy <- true // thread-safe
+
+	old := app.Snapshot()
+	s := old.clone()
+
+	isPartial := true
+	targets := app.reloadTargets(fpaths)
+	for _, fpath := range targets {
+		if !app.reloadPath(s, fpath) {
+			isPartial = false
+			break
+		}
+	}
+
+	if isPartial {
+		app.snap.Store(s)
+
+		// Rendered pages made from changed ones
+		app.invalidateCache(old, s, targets)
+
+		// Sitemap lists every page
+		app.createSitemap(s, app.PublicPath)
+		app.createFeeds(s, app.PublicPath)
+	}
+
+	<-app.chBusy
+
+	if !isPartial {
+		app.LoadContent()
+		return
+	}
+
+	// Run on every content load
+	if app.OnReload != nil {
+		app.OnReload(app)
+	}
+}
+
+// Normalize changed paths to pages that must be reloaded
+// Config files are changes for whole directory
+// Paths inside other given directories are skipped
+func (app *Application) reloadTargets(fpaths []string) []string {
+	targets := make([]string, 0, len(fpaths))
+	for _, fpath := range fpaths {
+		fpath, _ = filepath.Abs(fpath)
+
+		switch filepath.Base(fpath) {
+		case ".dir", ".defaults", ".subdefaults", schemaFname:
+			fpath = filepath.Dir(fpath)
+		}
+		targets = append(targets, fpath)
+	}
+
+	// Shorter (upper) paths first
+	sort.Strings(targets)
+
+	var _targets []string
+	for _, fpath := range targets {
+		isInside := false
+		for _, dir := range _targets {
+			if fpath == dir || strings.HasPrefix(fpath, dir+"/") {
+				isInside = true
+				break
+			}
+		}
+		if !isInside {
+			_targets = append(_targets, fpath)
+		}
+	}
+
+	return _targets
+}
+
+// Reload one file or directory
+// Returns false if only whole content reload can help
+func (app *Application) reloadPath(s *Snapshot, fpath string) bool {
+	dir := filepath.Dir(fpath)
+
+	if filepath.Base(fpath) == ".translations" {
+		app.loadTranslations(s)
+		return true
+	}
+
+	// Public file changed (FileURL with {Hash})
+	// Pages that link to it get fresh hash
+	if rpath := app.publicRelPath(fpath); rpath != "" {
+		pages := s.slugPages.Filter(func(p *Page) bool {
+			return bytes.Contains(p.rawContent(), []byte("/"+rpath))
+		})
+		done := make(map[*Page]bool, 0)
+		for _, p := range append(pages, app.dependentPages(s, pages)...) {
+			if !done[p] && p.IsSet("Path") {
+				done[p] = true
+				app.refreshPage(s, p)
+			}
+		}
+		return true
+	}
+
+	// Asset added, changed or removed
+	// Pages near it can link to it
+	if app.assetMode == assetsKeep || app.assetMode == assetsCopy {
+		if finfo, err := os.Stat(fpath); isAssetName(filepath.Base(fpath)) && (err != nil || !finfo.IsDir()) {
+			app.removeAsset(s, fpath)
+			if err == nil {
+				app.addAsset(s, fpath)
+			}
+			for _, p := range app.pagesByPath(s, dir) {
+				if filepath.Dir(p.Get("Path")) == dir {
+					app.refreshPage(s, p)
+				}
+			}
+			return true
+		}
+	}
+
+	// Language folders (and files near them) changes every page
+	if !strings.HasPrefix(dir, app.ContentPath+"/") {
+		return false
+	}
+
+	// Before changes find out who depends on this path
+	dependents := app.dependentPages(s, app.pagesByPath(s, fpath))
+
+	parent := app.pageByPath(s, dir)
+	if parent == nil {
+		// Hidden or not loaded directory
+		return false
+	}
+
+	// Remove old pages
+	// and their problems (found again on load)
+	app.removePages(s, fpath)
+	s.diagnostics.remove(fpath, true)
+
+	// Load again (if not removed)
+	if finfo, err := os.Stat(fpath); err == nil {
+		if p := app.loadPage(s, dir, finfo); p != nil {
+			p.Parent = parent
+
+			if !p.IsYes("IsUnlisted") {
+				parent.Lock()
+				parent.Pages = append(parent.Pages[:len(parent.Pages):len(parent.Pages)], p)
+				parent.Unlock()
+				app.sortPages(parent)
+			}
+
+			// New pages can have dependents too
+			newPages := app.pagesByPath(s, fpath)
+			for _, p2 := range newPages {
+				app.afterLoadPage(s, p2)
+			}
+			dependents = append(dependents, app.dependentPages(s, newPages)...)
+		}
+	}
+
+	// Rebuild content of pages that takes it from changed pages
+	done := make(map[*Page]bool, 0)
+	for _, p := range dependents {
+		path := p.Get("Path")
+		if done[p] || path == fpath || strings.HasPrefix(path, fpath+"/") {
+			// Already fresh
+			continue
+		}
+		done[p] = true
+		app.refreshPage(s, p)
+	}
+
+	return true
+}
+
+// Path relative to PublicPath if file is there (not in content)
+// Returns "" for other files
+func (app *Application) publicRelPath(fpath string) string {
+	if strings.HasPrefix(fpath, app.ContentPath+"/") || !strings.HasPrefix(fpath, app.PublicPath+"/") {
+		return ""
+	}
+	return filepath.ToSlash(strings.TrimPrefix(fpath, app.PublicPath+"/"))
+}
+
+// Find loaded page by absolute file path
+func (app *Application) pageByPath(s *Snapshot, fpath string) *Page {
+	if pages := s.slugPages.Filter(func(p *Page) bool {
+		return p.IsEqual("Path", fpath)
+	}); len(pages) > 0 {
+		return pages[0]
+	}
+	return nil
+}
+
+// Find loaded page and all sub-pages by absolute file path
+// Unlisted pages are included
+func (app *Application) pagesByPath(s *Snapshot, fpath string) PageList {
+	return s.slugPages.Filter(func(p *Page) bool {
+		path := p.Get("Path")
+		return path == fpath || strings.HasPrefix(path, fpath+"/")
+	})
+}
+
+// Remove page (and sub-pages) by absolute file path
+// from slugPages, collections and parent
+func (app *Application) removePages(s *Snapshot, fpath string) {
+	for _, p := range app.pagesByPath(s, fpath) {
+		s.slugPages.Remove(p.Get("Slug"))
+		s.search.removePage(p)
+
+		for _, c := range s.collections {
+			c.RemovePage(p)
+		}
+
+		if parent := p.Parent; parent != nil {
+			parent.Lock()
+			var pages PageList
+			for _, p2 := range parent.Pages {
+				if p2 != p {
+					pages = append(pages, p2)
+				}
+			}
+			parent.Pages = pages
+			parent.Unlock()
+		}
+	}
+}
+
+// Sort sub-pages same way as on full load
+// Directory order first and then by page "Sort" param
+func (app *Application) sortPages(page *Page) {
+	page.Lock()
+	pages := append(PageList{}, page.Pages...)
+	page.Unlock()
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Get("Path") < pages[j].Get("Path")
+	})
+	pages.Sort(page.Get("Sort"))
+
+	page.Lock()
+	page.Pages = pages
+	page.Unlock()
+}
+
+// Pages that use given pages in "ContentFrom" or "Redirect"
+func (app *Application) dependentPages(s *Snapshot, pages PageList) PageList {
+	if len(pages) == 0 {
+		return nil
+	}
+
+	// Everything that can be referenced
+	refs := make(map[string]bool, 0)
+	urls := make(map[string]bool, 0)
+	for _, p := range pages {
+		refs[p.Get("Slug")] = true
+		urls[p.Get("URL")] = true
+
+		// Directory content is made from sub-pages
+		if p.Parent != nil {
+			refs[p.Parent.Get("Slug")] = true
+		}
+
+		for ckey := range s.collections {
+			for _, citem := range p.Split(ckey, ",") {
+				refs[strings.ToLower(ckey+":"+citem)] = true
+			}
+		}
+	}
+
+	return s.slugPages.Filter(func(p *Page) bool {
+		if cfrom := p.Get("ContentFrom"); cfrom != "" {
+			// Query can find any page
+			if refs[cfrom] || strings.HasPrefix(cfrom, "?") {
+				return true
+			}
+
+			// Collection keys are case insensitive
+			// Plural forms too
+			if arr := strings.SplitN(cfrom, ":", 2); len(arr) == 2 {
+				if c := s.Collection(arr[0]); c != nil {
+					for ckey := range s.collections {
+						if s.collections[ckey] == c && refs[strings.ToLower(ckey+":"+strings.TrimSpace(arr[1]))] {
+							return true
+						}
+					}
+				}
+			}
+		}
+
+		return p.IsSet("Redirect") && urls[p.Get("Redirect")]
+	})
+}
+
+// Parse page file again (params stays the same)
+// and do post-load operations with fresh content
+func (app *Application) refreshPage(s *Snapshot, page *Page) {
+	s.diagnostics.remove(page.Get("Path"), false)
+
+	p2 := fileToPage(app, page.Get("Path"), s.diagnostics)
+	page.SetContent(p2.content)
+	page.setShortcodes(p2.shortcodes)
+	page.setHeadings(p2.headings)
+	page.Set("HaveContent", p2.Get("HaveContent"))
+	page.copyContentStats(p2)
+	page.Set("BreadCrumbs", "")
+	if p2.IsSet("Redirect") {
+		page.Set("Redirect", p2.Get("Redirect"))
+	}
+
+	app.afterLoadPage(s, page)
+}
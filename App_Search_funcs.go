@@ -0,0 +1,58 @@
+package mango
+
+// Found pages on one page of search results (if not set by "SearchSize" param)
+const searchPageSize = 10
+
+// Search - find pages under given top page by search term
+// Best matches first. Words in double quotes are phrase:
+// app.Search("en", `fruits "green apple"`)
+func (app *Application) Search(pageSlug, sterm string) PageList {
+	return app.Snapshot().Search(pageSlug, sterm)
+}
+
+// SearchResults - same as Search, but with scores and snippets
+func (app *Application) SearchResults(pageSlug, sterm string) []SearchResult {
+	return app.Snapshot().SearchResults(pageSlug, sterm)
+}
+
+// Search - find pages under given top page by search term
+func (s *Snapshot) Search(pageSlug, sterm string) PageList {
+	var pages PageList
+	for _, res := range s.SearchResults(pageSlug, sterm) {
+		pages = append(pages, res.Page)
+	}
+	return pages
+}
+
+// SearchResults - found pages under given top page with scores and snippets
+// Only pages listed under top page are found (as in page.Walk)
+func (s *Snapshot) SearchResults(pageSlug, sterm string) []SearchResult {
+	top := s.Page(pageSlug) // from where to start search
+	if top == nil {
+		return nil
+	}
+
+	// Language root has no "Lang", using Slug because its lang page
+	lang := top.Get("Lang")
+	if lang == "" && top.IsEqual("Level", "0") {
+		lang = top.Get("Slug")
+	}
+
+	return s.search.search(sterm, lang, func(p *Page) bool {
+		return !p.isTopLevel() && isListedUnder(p, top)
+	})
+}
+
+// Is page in sub-pages of top page (at any depth)
+// Unlisted pages and pages under them are not
+func isListedUnder(page, top *Page) bool {
+	for p := page; p.Parent != nil; p = p.Parent {
+		if p.IsYes("IsUnlisted") {
+			return false
+		}
+		if p.Parent == top {
+			return true
+		}
+	}
+	return false
+}
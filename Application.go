@@ -2,12 +2,15 @@ package mango
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -36,22 +39,12 @@ type Application struct {
 	// Absolute path to web accessible files
 	PublicPath string
 
-	// Page tree
-	Pages PageList
-
-	// Easy count overall pages and detect duplicates
-	// map[Slug]Page
-	slugPages PageMap
-
-	// Collectables - pages that are collected
-	// Example: "Tag: dog, cat, mouse" --> every tag will point to one *Page
-	// Case sensitive
-	collections map[string]*Collection
+	// Loaded content (*Snapshot)
+	// Swapped atomically on every reload
+	snap atomic.Value
 
-	// Translations
-	// If no need to create new .md file but need translate one string
-	// translations[lv][Hello] = "Labdien!"
-	translations map[string]map[string]string
+	// Collection keys from config
+	collectionKeys []string
 
 	// URLTemplates - url templates for pages
 	URLTemplates map[string]string
@@ -64,20 +57,113 @@ type Application struct {
 
 	// Run this func on content reload
 	OnReload func(app *Application)
+
+	// Shortcodes added to built-in ones (see AddShortcode)
+	shortcodes map[string]ShortcodeFunc
+
+	// Markdown renderer (nil - default, see SetMarkdown)
+	markdown MarkdownRenderer
+
+	// Markdown extensions from config (page params can override)
+	markdownOptions MarkdownOptions
+
+	// Colors of highlighted code in highlight.css (from config "HighlightStyle: monokai")
+	highlightStyle string
+
+	// Items in feeds (from config "FeedSize: 50")
+	feedSize int
+
+	// Gzip sitemaps (from config "SitemapGzip: Yes")
+	isSitemapGzip bool
+
+	// Reloads content on changes in ContentPath (nil if not watching)
+	watcher *Watcher
+
+	// Watcher settings from config
+	watchMode     string
+	watchDelay    time.Duration
+	watchInterval time.Duration
+
+	// Fail on content errors (from config "Strict: Yes")
+	isStrict bool
+
+	// What to do with not page files in ContentPath
+	// Move (default), Keep or Copy
+	assetMode string
+
+	// Widths of resized image variants (from config "ImageWidths: 320, 640")
+	imageWidths []int
+
+	// Remove EXIF from public jpeg images (from config "StripEXIF: Yes")
+	isStripEXIF bool
+
+	// Content hashes of public files for FileURL {Hash}
+	fileHashes fileHashes
+
+	// Default Cache-Control header for pages (from config)
+	cacheControl string
+
+	// Size of rendered page cache (from config "CacheSize: 32MB", 0 - off)
+	cacheSize int64
 }
 
 // NewApplication - create/init new application
 // Must be executed only one time
 func NewApplication() (*Application, error) {
+	return NewApplicationAt("")
+}
+
+// NewApplicationAt - create/init new application
+// from given path instead of path where binary is.
+// Config file ".mango" must be there
+// In strict mode content errors are returned (with loaded app)
+// Watcher errors are returned too (with loaded app)
+func NewApplicationAt(binPath string) (*Application, error) {
+	app, err := OpenApplication(binPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load
+	app.LoadContent()
+
+	// Content problems are not fatal unless asked so
+	if app.isStrict {
+		if err := app.Snapshot().diagnostics.Err(); err != nil {
+			return app, err
+		}
+	}
+
+	// Reload automatically when content changes
+	if app.watchMode == _Yes || app.watchMode == "Poll" {
+		w, err := app.Watch(app.watchMode == "Poll")
+		if err != nil {
+			return app, fmt.Errorf("can't watch content: %v", err)
+		}
+		app.watcher = w
+	}
+
+	return app, nil
+}
+
+// OpenApplication - configured application without loaded content
+// Nothing is changed on filesystem. Use LoadContent or Lint after
+func OpenApplication(binPath string) (*Application, error) {
 	app := &Application{}
 
 	// throughput: 1
 	// Only one can be manipulating with Application at one moment
 	// avoiding concurrency errors
 	app.chBusy = make(chan bool, 1)
+	app.snap.Store(newSnapshot(nil))
+	app.fileHashes.m = make(map[string]fileHash, 0)
 
 	// Set defaults
 	app.setBinPath()
+	if binPath != "" {
+		binPath, _ = filepath.Abs(binPath)
+		app.binPath = binPath
+	}
 	app.ContentPath = app.binPath + "/content"
 	app.PublicPath = app.binPath + "/public"
 
@@ -95,12 +181,14 @@ func NewApplication() (*Application, error) {
 	// Override defaults (as last action)
 	app.loadConfig(".mango")
 
-	// Load
-	app.LoadContent()
-
 	return app, nil
 }
 
+// Watcher - get running content watcher (nil if not watching)
+func (app *Application) Watcher() *Watcher {
+	return app.watcher
+}
+
 // Detect bin path from where binary executed
 func (app *Application) setBinPath() error {
 	path, err := filepath.Abs(filepath.Dir(os.Args[0]))
@@ -116,11 +204,32 @@ func (app *Application) setBinPath() error {
 	return nil
 }
 
+// Snapshot - currently loaded content
+// Hold it for whole request to get consistent content
+func (app *Application) Snapshot() *Snapshot {
+	return app.snap.Load().(*Snapshot)
+}
+
+// Pages - page tree of currently loaded content
+func (app *Application) Pages() PageList {
+	return app.Snapshot().Pages
+}
+
+// Diagnostics - problems found while loading current content
+func (app *Application) Diagnostics() []Diagnostic {
+	return app.Snapshot().Diagnostics()
+}
+
 // BinPath - get bin path
 func (app *Application) BinPath() string {
 	return app.binPath
 }
 
+// Directory of templates (minified ones in "min" sub-directory)
+func (app *Application) templatePath() string {
+	return app.BinPath() + "/templates"
+}
+
 // loadConfig using given config filename
 // usually ".mango"
 // Should not be tested for parallel because used only once in init
@@ -154,8 +263,6 @@ func (app *Application) loadConfig(fname string) {
 		path, _ = filepath.Abs(path)
 		app.PublicPath = filepath.Clean(path)
 	}
-	os.MkdirAll(app.PublicPath+"/images/", 0755) // where all images from content path moved
-	os.MkdirAll(app.PublicPath+"/data/", 0755)   // other file smoved here
 
 	// Template for construction page url's
 	if urlTemplate := params["PageURL"]; urlTemplate != "" {
@@ -170,9 +277,75 @@ func (app *Application) loadConfig(fname string) {
 	}
 	app.URLTemplates["File"] = strings.Replace(app.URLTemplates["File"], "{File}", "{File:.+}", -1)
 
+	// Built-in search page: /{Lang}/search?q=apples
+	// No route if not set
+	if urlTemplate := params["SearchURL"]; urlTemplate != "" {
+		app.URLTemplates["Search"] = urlTemplate
+	}
+
+	// Cache-Control for pages (can be overridden by page "CacheControl" param)
+	app.cacheControl = params["CacheControl"]
+
+	// Rendered pages kept in memory (off by default)
+	// CacheSize: 32MB	-- also KB, GB or plain bytes
+	app.cacheSize = parseByteSize(params["CacheSize"])
+
+	// Content hash of file for long-term caching: /static/{Hash}/{File}
+	// (or in query: /{File}?v={Hash})
+	if arr := strings.SplitN(app.URLTemplates["File"], "?", 2); len(arr) == 2 {
+		app.URLTemplates["File"] = strings.Replace(arr[0], "{Hash}", "{Hash:[^/]+}", -1) + "?" + arr[1]
+	} else {
+		app.URLTemplates["File"] = strings.Replace(arr[0], "{Hash}", "{Hash:[^/]+}", -1)
+	}
+
+	// Watch content changes and reload in background
+	// Watch: Yes		-- filesystem events (polling if not available)
+	// Watch: Poll		-- always polling
+	app.watchMode = params["Watch"]
+	if d, err := time.ParseDuration(params["WatchDelay"]); err == nil {
+		app.watchDelay = d
+	}
+	if d, err := time.ParseDuration(params["WatchInterval"]); err == nil {
+		app.watchInterval = d
+	}
+
+	// Assets: Move	-- move to PublicPath/images and PublicPath/data
+	// Assets: Keep	-- serve from ContentPath by content-relative path
+	// Assets: Copy	-- copy to PublicPath/assets by content-relative path
+	app.assetMode = assetsMove
+	switch params["Assets"] {
+	case assetsKeep, assetsCopy:
+		app.assetMode = params["Assets"]
+	}
+
+	// Image pipeline
+	// ImageWidths: 480, 960	-- logo.png --> logo-480w.png, logo-960w.png
+	// StripEXIF: Yes			-- remove camera, GPS.. info from jpeg
+	app.imageWidths = parseWidths(params["ImageWidths"])
+	app.isStripEXIF = params["StripEXIF"] == _Yes
+
+	// Markdown extensions
+	// MarkdownFootnotes: Yes, MarkdownHardLineBreaks: Yes, MarkdownHeadingIDPrefix: doc-..
+	app.markdownOptions = markdownOptions(defaultMarkdownOptions, params)
+	app.highlightStyle = params["HighlightStyle"]
+
+	// Feeds of language roots, directories with "IsFeed: Yes" and collection items
+	// FeedSize: 20	-- newest pages in feed
+	app.feedSize = defaultFeedSize
+	if n, err := strconv.Atoi(params["FeedSize"]); err == nil && n > 0 {
+		app.feedSize = n
+	}
+
+	// Large sites: sitemap.xml is index of language sitemaps (sitemap-en.xml)
+	// SitemapGzip: Yes	-- sitemap.xml.gz (or sitemap-en.xml.gz)
+	app.isSitemapGzip = params["SitemapGzip"] == _Yes
+
+	// Content errors makes NewApplication fail
+	app.isStrict = params["Strict"] == _Yes
+
 	// Init collections
 	// Collections: Tags, Categories, Keywords--> init 3 collection page maps
-	app.collections = make(map[string]*Collection, 0) // make anyways
+	app.collectionKeys = make([]string, 0) // make anyways
 	if params["Collections"] == "" {
 		params["Collections"] = "Tags, Categories, Keywords" // default collections
 	}
@@ -185,38 +358,39 @@ func (app *Application) loadConfig(fname string) {
 			}
 
 			// Init
-			// Add empty to later know what we are collecting (in app.LoadContent)
-			app.collections[ckey] = NewCollection()
+			// Every snapshot creates collection for every key
+			app.collectionKeys = append(app.collectionKeys, ckey)
 		}
 	}
 
 }
 
 // LoadContent - Load files to application
-// Can be executed more than once times (on .reload file creation)
+// Can be executed more than once times (by Watcher on content changes)
 func (app *Application) LoadContent() {
 	app.chBusy <- true // thread-safe
 
-	// Init pageList
-	app.slugPages.MakeEmpty()
+	os.MkdirAll(app.PublicPath+"/images/", 0755) // where all images from content path moved
+	os.MkdirAll(app.PublicPath+"/data/", 0755)   // other file smoved here
 
-	// Clear collections
-	for ckey := range app.collections {
-		app.collections[ckey].MakeEmpty()
-	}
+	// Build everything in new snapshot
+	// Readers still use previous one
+	s := app.loadSnapshot(false)
 
-	// Page tree
-	app.Pages = app.loadPages(app.ContentPath)
+	// Swap whole content at once
+	app.snap.Store(s)
 
-	// Post-load operations
-	// Edit page after all pages loaded
-	app.afterLoadContent()
+	// Any rendered page can be changed
+	app.invalidateCache(nil, s, nil)
 
-	// Load translations from every language folder
-	app.loadTranslations()
+	// Create sitemap.xml (and language sitemaps) under public path
+	app.createSitemap(s, app.PublicPath)
 
-	// Create sitemap.xml under public path
-	app.createSitemap()
+	// RSS, Atom and JSON feeds
+	app.createFeeds(s, app.PublicPath)
+
+	// Colors for highlighted code
+	app.createHighlightCSS(s, app.PublicPath)
 
 	<-app.chBusy
 
@@ -226,308 +400,374 @@ func (app *Application) LoadContent() {
 	}
 }
 
+// LoadContentDryRun - load files to application without changing filesystem
+// Assets are not moved or copied, sitemap, feeds and highlight.css
+// are not written (Export writes them to its own directory)
+func (app *Application) LoadContentDryRun() {
+	app.chBusy <- true // thread-safe
+
+	s := app.loadSnapshot(true)
+	app.snap.Store(s)
+	app.invalidateCache(nil, s, nil)
+
+	<-app.chBusy
+}
+
+// Load content again the same way (dry run or not) as current snapshot
+// Nothing is done if content is not loaded yet
+func (app *Application) reloadContent() {
+	s := app.Snapshot()
+	switch {
+	case !s.isLoaded:
+		return
+	case s.isDryRun:
+		app.LoadContentDryRun()
+	default:
+		app.LoadContent()
+	}
+}
+
+// Load whole content to new snapshot
+func (app *Application) loadSnapshot(isDryRun bool) *Snapshot {
+	s := newSnapshot(app.collectionKeys)
+	s.isDryRun = isDryRun
+	s.isLoaded = true
+	s.diagnostics.schemas = newSchemaCache(app.ContentPath)
+
+	// Assets first so pages can link to them
+	app.loadAssets(s)
+
+	// Page tree
+	s.Pages = app.loadPages(s, app.ContentPath)
+
+	// Post-load operations
+	// Edit page after all pages loaded
+	app.afterLoadContent(s)
+
+	// Load translations from every language folder
+	app.loadTranslations(s)
+
+	return s
+}
+
 // Directory to page tree
-func (app *Application) loadPages(fpath string) PageList {
+func (app *Application) loadPages(s *Snapshot, fpath string) PageList {
 
 	// Collect all pages
 	var pages PageList
-	if files, dirErr := ioutil.ReadDir(fpath); dirErr == nil {
+	files, dirErr := ioutil.ReadDir(fpath)
+	if dirErr != nil {
+		s.diagnostics.Add(fpath, 0, SeverityError, "can't read directory: %v", dirErr)
+	} else {
+		// Assets first, so pages in same directory
+		// can link to them (image variants)
 		for _, f2 := range files {
-			if f2.Name()[0] == '.' {
-				// Skip config files (e.g. .dir, .defaults..)
-				continue
-			}
-
-			// Not dir and not .md
-			// move to public path
-			ext := filepath.Ext(f2.Name())
-			ext = strings.ToLower(ext)
-			if !f2.IsDir() && ext != _Md {
-				images := ".png, .gif, .jpg, .jpeg, .svg," // comma-ended
-				mvPath := app.PublicPath + "/images/" + f2.Name()
-				if strings.Index(images, ext) == -1 {
-					// Images move to /public/data/
-					mvPath = app.PublicPath + "/data/" + f2.Name()
-				}
-				os.Rename(fpath+"/"+f2.Name(), mvPath)
-				continue
+			if !f2.IsDir() && isAssetName(f2.Name()) {
+				app.loadAsset(s, fpath, f2.Name())
 			}
+		}
 
-			// Parse valid page file
-			p := app.FileToPage(fpath + "/" + f2.Name())
-
-			if !p.IsYes("IsVisible") {
-				// Only visible pages are added
+		for _, f2 := range files {
+			if !f2.IsDir() && isAssetName(f2.Name()) {
 				continue
 			}
-
-			// Can't be duplicate slugs
-			if p.isDuplicate() {
-				p.avoidDuplicate()
-			}
-
-			// Add to linear slugPages
-			// app.slugPages[p.Params["Slug"]] = p
-			app.slugPages.Add(p.Get("Slug"), p)
+			p := app.loadPage(s, fpath, f2)
 
 			// If page is unlisted do not add it to tree
 			// (but leave in linear list of pages)
 			// That means it can be found by slug,
 			// but can't be found among parent childrens
-			if !p.IsYes("IsUnlisted") {
+			if p != nil && !p.IsYes("IsUnlisted") {
 				// Add to pageTree
 				pages = append(pages, p)
 			}
+		}
+	}
 
-			// Load .defaults page as separate page
-			// only .defaults that are in same level as language folders
-			// This page will be used on creation of new pages to set default params
-			if p.IsEqual("Level", "0") {
-				pDef := app.FileToPage(fpath + "/" + f2.Name() + "/.defaults")
-				// Nothing on p.Get("Lang") so using Slug because its lang page
-				app.slugPages.Add("."+p.Get("Slug")+"-defaults", pDef) // .en-defaults
-			}
+	return pages
+}
 
-			// After all go deeper.
-			// Depth loader must be executed last so top pages are added first
-			// Like, "content/en" and "content/lv" are saved and depth pages
-			// can reference to them immediately
-			//
-			// Load sub-pages if it's directory
-			if p.IsDir() {
-				p.Pages = app.loadPages(p.Get("Path"))
-				p.Pages.Sort(p.Get("Sort"))
-
-				// Add parent to received pages
-				for _, p2 := range p.Pages {
-					// Set Parent page for all sub-pages
-					p2.Parent = p
-				}
-			}
+// Load one directory entry (and sub-pages if it's directory)
+// Returns nil if entry is not a visible page
+func (app *Application) loadPage(s *Snapshot, fpath string, f2 os.FileInfo) *Page {
+	if f2.Name()[0] == '.' {
+		// Skip config files (e.g. .dir, .defaults..)
+		return nil
+	}
+
+	// Not dir and not .md
+	// move to public path
+	ext := filepath.Ext(f2.Name())
+	ext = strings.ToLower(ext)
+	if !f2.IsDir() && ext != _Md {
+		app.loadAsset(s, fpath, f2.Name())
+		return nil
+	}
+
+	// Parse valid page file
+	p := app.filePage(s, fpath+"/"+f2.Name())
+
+	if !p.IsYes("IsVisible") {
+		// Only visible pages are added
+		return nil
+	}
+
+	// Can't be duplicate slugs
+	// Slug changed, so url too
+	if p.isDuplicate() {
+		slug := p.Get("Slug")
+		p.avoidDuplicate()
+		p.Set("URL", app.pageURL(p))
+		s.diagnostics.addParam(p.Get("Path"), "Slug", SeverityWarning, "duplicate slug %q renamed to %q", slug, p.Get("Slug"))
+	}
+
+	// Add to linear slugPages
+	// s.slugPages[p.Params["Slug"]] = p
+	s.slugPages.Add(p.Get("Slug"), p)
+
+	// Load .defaults page as separate page
+	// only .defaults that are in same level as language folders
+	// This page will be used on creation of new pages to set default params
+	if p.IsEqual("Level", "0") {
+		pDef := app.filePage(s, fpath+"/"+f2.Name()+"/.defaults")
+		// Nothing on p.Get("Lang") so using Slug because its lang page
+		s.slugPages.Add("."+p.Get("Slug")+"-defaults", pDef) // .en-defaults
+	}
 
+	// After all go deeper.
+	// Depth loader must be executed last so top pages are added first
+	// Like, "content/en" and "content/lv" are saved and depth pages
+	// can reference to them immediately
+	//
+	// Load sub-pages if it's directory
+	if p.IsDir() {
+		p.Pages = app.loadPages(s, p.Get("Path"))
+		p.Pages.Sort(p.Get("Sort"))
+
+		// Add parent to received pages
+		for _, p2 := range p.Pages {
+			// Set Parent page for all sub-pages
+			p2.Parent = p
 		}
 	}
 
-	return pages
+	return p
 }
 
 // Post-load operations
 // Edit page after all pages loaded
 // For example param: ContentFrom, can be used only after all pages loaded
-func (app *Application) afterLoadContent() {
+func (app *Application) afterLoadContent(s *Snapshot) {
 
 	// Do filter walk but don't collect pages
-	app.slugPages.Filter(func(p *Page) bool {
+	s.slugPages.Filter(func(p *Page) bool {
+		app.afterLoadPage(s, p)
+		return false
+	})
+}
 
-		// What separator to use by appending content
-		sepTemplate := []byte("\n{{ Content }}")
-		if _sepTemplate := p.Get("ContentTemplate"); _sepTemplate != "" {
-			sepTemplate = []byte(_sepTemplate)
-		}
+// Post-load operations for one page
+func (app *Application) afterLoadPage(s *Snapshot, p *Page) {
 
-		// *** ContentFrom:
-		if cfrom := p.Get("ContentFrom"); cfrom != "" {
-			if strings.HasPrefix(cfrom, "http://") || strings.HasPrefix(cfrom, "https://") {
-				response, err := http.Get(cfrom)
-				if err == nil {
-					defer response.Body.Close()
-					urlContent, urlErr := ioutil.ReadAll(response.Body)
-					if urlErr == nil {
-						p.SetContent(urlContent)
-					}
-				}
+	// What separator to use by appending content
+	sepTemplate := []byte("\n{{ Content }}")
+	if _sepTemplate := p.Get("ContentTemplate"); _sepTemplate != "" {
+		sepTemplate = []byte(_sepTemplate)
+	}
 
-			} else if strings.HasPrefix(cfrom, ".") || strings.HasPrefix(cfrom, "/") {
-				// Any Filesystem file with path traversal
-				// ../../../ or ./readme.txt
-				if strings.HasSuffix(strings.ToLower(cfrom), ".md") {
-					// markdown
-					if filePage := fileToPage(cfrom); filePage != nil {
-						p.SetContent(filePage.Content())
-					}
-				} else {
-					// raw
-					if buf, err := ioutil.ReadFile(cfrom); err == nil {
-						p.SetContent(buf)
-					}
-				}
+	// Problems are reported on page file
+	path := p.Get("Path")
 
-			} else if strings.Index(cfrom, ":") > 0 {
-				// From collection
-				arr := strings.SplitN(cfrom, ":", 2)
-				ckey := arr[0]
-				citem := arr[1]
+	// *** References to other page params: {Parent.Title}
+	app.resolveParamRefs(s, p)
 
-				pages := app.CollectionPages(ckey, citem)
-				pages.Sort(p.Get("Sort"))
+	// *** ContentFrom:
+	if cfrom := p.Get("ContentFrom"); cfrom != "" {
+		if strings.HasPrefix(cfrom, "http://") || strings.HasPrefix(cfrom, "https://") {
+			// Error pages are not content (page keeps its own)
+			if urlContent, err := fetchURL(cfrom); err != nil {
+				s.diagnostics.addParam(path, "ContentFrom", SeverityError, "can't fetch ContentFrom %q: %v", cfrom, err)
+			} else {
+				p.SetContent(urlContent)
+			}
 
-				// Load content from sub-pages
-				for _, p3 := range pages {
+		} else if strings.HasPrefix(cfrom, "?") {
+			// From pages found by query
+			// ?Tags:dog sort:-ModTime limit:5
+			q, err := parsePageQuery(cfrom[1:])
+			if err != nil {
+				s.diagnostics.addParam(path, "ContentFrom", SeverityError, "ContentFrom %q: %v", cfrom, err)
+			} else {
+				for _, p3 := range s.runQuery(q, nil) {
+					if p3 == p {
+						continue
+					}
 					content := bytes.Replace(sepTemplate, []byte("{{ Content }}"), p3.Content(), 1)
 					p.AppendContent(content)
 				}
+				p.Set("HaveContent", _Yes)
+			}
 
-			} else if page2 := app.Page(cfrom); page2 != nil {
-				// From slug
-
-				// Make content
-				if page2.IsDir() {
-
-					// Load content from sub-pages
-					for _, p3 := range page2.Pages {
-						content := bytes.Replace(sepTemplate, []byte("{{ Content }}"), p3.Content(), 1)
-						p.AppendContent(content)
-					}
-
+		} else if strings.HasPrefix(cfrom, ".") || strings.HasPrefix(cfrom, "/") {
+			// Any Filesystem file with path traversal
+			// ../../../ or ./readme.txt
+			if strings.HasSuffix(strings.ToLower(cfrom), ".md") {
+				// markdown
+				if _, err := os.Stat(cfrom); err != nil {
+					s.diagnostics.addParam(path, "ContentFrom", SeverityError, "can't read ContentFrom %q: %v", cfrom, err)
+				} else if filePage := fileToPage(app, cfrom, s.diagnostics); filePage != nil {
+					p.SetContent(filePage.Content())
+					p.setHeadings(filePage.headings)
+				}
+			} else {
+				// raw
+				if buf, err := ioutil.ReadFile(cfrom); err == nil {
+					p.SetContent(buf)
 				} else {
-					// Content from one page
-					content := bytes.Replace(sepTemplate, []byte("{{ Content }}"), page2.Content(), 1)
-					p.AppendContent(content)
+					s.diagnostics.addParam(path, "ContentFrom", SeverityError, "can't read ContentFrom %q: %v", cfrom, err)
 				}
-
-				p.Set("HaveContent", _Yes)
 			}
 
-		}
+		} else if strings.Index(cfrom, ":") > 0 {
+			// From collection
+			arr := strings.SplitN(cfrom, ":", 2)
+			ckey := arr[0]
+			citem := arr[1]
 
-		// *** BreadCrumbs:
-		// Add breadcrumb by walking to top by parents
-		// For breadcrumbs cant use only filepath because slugs can be different
-		p.WalkTop(func(parent *Page) {
-			crumbs := parent.Get("Slug") + " / " + p.Get("BreadCrumbs")
-			p.Set("BreadCrumbs", crumbs)
-		})
-
-		// *** Redirect:
-		// 1. Try to get page by redirect slug (if not language root page)
-		// 2. add / at the beginning if not absolute url
-		if s := p.Get("Redirect"); s != "" {
-			url := s
-			if p2 := app.Page(s); p2 != nil {
-				// Destination page found assign it's URL
-				url = p2.Get("URL")
-
-			} else if s[0] != '/' && s[0] != '?' && strings.Index(s, ":") == -1 {
-				url = "/" + s
+			if s.Collection(ckey) == nil {
+				s.diagnostics.addParam(path, "ContentFrom", SeverityWarning, "ContentFrom %q: unknown collection %q", cfrom, ckey)
 			}
-			p.Set("Redirect", url)
-			p.Set("URL", url)
-			// fmt.Println(p.Get("URL"), url)
-		}
 
-		return false
-	})
-}
+			pages := s.CollectionPages(ckey, citem)
+			pages.Sort(p.Get("Sort"))
 
-// Load translations from every language folder
-func (app *Application) loadTranslations() {
-	app.translations = make(map[string]map[string]string, 0)
+			// Load content from sub-pages
+			for _, p3 := range pages {
+				content := bytes.Replace(sepTemplate, []byte("{{ Content }}"), p3.Content(), 1)
+				p.AppendContent(content)
+			}
 
-	// Loop only first level (it's language folders)
-	for _, p := range app.Pages {
-		fpath := p.Get("Path") + "/.translations"
-		buf, _ := ioutil.ReadFile(fpath)
+		} else if page2 := s.Page(cfrom); page2 != nil {
+			// From slug
 
-		translations := bufToParams(buf, false)
-		app.translations[p.Get("Slug")] = translations
-	}
-}
+			// Make content
+			if page2.IsDir() {
 
-// Search pages from given top page
-func (app *Application) Search(pageSlug, sterm string) PageList {
-	page := app.Page(pageSlug) // from where to start search
+				// Load content from sub-pages
+				for _, p3 := range page2.Pages {
+					content := bytes.Replace(sepTemplate, []byte("{{ Content }}"), p3.Content(), 1)
+					p.AppendContent(content)
+				}
 
-	// If search is activated when app.LoadContent is in process
-	// there could be situation where app.Page(slug) is empty
-	if page == nil {
-		return nil
-	}
+			} else {
+				// Content from one page
+				content := bytes.Replace(sepTemplate, []byte("{{ Content }}"), page2.Content(), 1)
+				p.AppendContent(content)
+			}
 
-	// Success results
-	return page.Search(sterm)
-}
+			p.Set("HaveContent", _Yes)
 
-// CreateSitemap - sitemap.xml
-func (app *Application) createSitemap() {
-	filepath := app.PublicPath + "/sitemap.xml"
-	// tCreated := time.Now()
-
-	contents := `<?xml version="1.0" encoding="UTF-8"?>
-<urlset
-xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
-xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-xsi:schemaLocation="http://www.sitemaps.org/schemas/sitemap/0.9
-http://www.sitemaps.org/schemas/sitemap/0.9/sitemap.xsd" >
-`
-	// Language roots
-	if strings.Index(app.URLTemplates["Page"], "{Lang") >= 0 {
-		for _, p := range app.Pages {
-			contents += "<url>\n"
-			contents += "\t<loc>" + p.AbsoluteURL() + "</loc>\n"
-			contents += "</url>\n"
+		} else {
+			s.diagnostics.addParam(path, "ContentFrom", SeverityError, "ContentFrom %q: page not found", cfrom)
 		}
+
 	}
 
-	// All pageList
-	for _, p := range app.slugPages.m {
+	// *** BreadCrumbs:
+	// Add breadcrumb by walking to top by parents
+	// For breadcrumbs cant use only filepath because slugs can be different
+	p.WalkTop(func(parent *Page) {
+		crumbs := parent.Get("Slug") + " / " + p.Get("BreadCrumbs")
+		p.Set("BreadCrumbs", crumbs)
+	})
 
-		if p.IsYes("IsUnlisted") {
-			// Unlisted pages must no be in sitemap
-			continue
+	// *** Redirect:
+	// 1. Try to get page by redirect slug (if not language root page)
+	// 2. add / at the beginning if not absolute url
+	if redirect := p.Get("Redirect"); redirect != "" {
+		url := redirect
+		if p2 := s.Page(redirect); p2 != nil {
+			// Destination page found assign it's URL
+			url = p2.Get("URL")
+
+		} else if redirect[0] != '/' && redirect[0] != '?' && strings.Index(redirect, ":") == -1 {
+			url = "/" + redirect
 		}
+		p.Set("Redirect", url)
+		p.Set("URL", url)
+		// fmt.Println(p.Get("URL"), url)
+	}
 
-		if p.IsEqual("IsSitemap", "No") {
-			// Some pages can be removed only from sitemap
-			continue
-		}
+	// *** Search:
+	// Content is final now
+	if !s.isDryRun {
+		s.search.add(p, app.collectionKeys)
+	}
+}
 
-		if p.IsSet("Redirect") {
-			// Redirect pages also not inside
-			continue
-		}
+// Get body of url
+// Error status (4xx, 5xx) is error too
+func fetchURL(url string) ([]byte, error) {
+	response, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
 
-		contents += "<url>\n"
-		contents += "\t<loc>" + p.AbsoluteURL() + "</loc>\n"
-		contents += "\t<lastmod>" + p.ModTime().Format(time.RFC3339) + "</lastmod>\n"
-		contents += "</url>\n"
+	if response.StatusCode >= 400 {
+		return nil, fmt.Errorf("responded %s", response.Status)
 	}
+	return ioutil.ReadAll(response.Body)
+}
 
-	contents += "</urlset>"
+// Load translations from every language folder
+func (app *Application) loadTranslations(s *Snapshot) {
+	s.translations = make(map[string]map[string]string, 0)
 
-	// write whole the body
-	ioutil.WriteFile(filepath, []byte(contents), 0644)
+	// Loop only first level (it's language folders)
+	for _, p := range s.Pages {
+		fpath := p.Get("Path") + "/.translations"
+		s.diagnostics.remove(fpath, false)
+		buf, err := ioutil.ReadFile(fpath)
+		if err != nil && !os.IsNotExist(err) {
+			s.diagnostics.Add(fpath, 0, SeverityError, "can't read file: %v", err)
+		}
 
+		translations := bufToParams(buf, false, fpath, s.diagnostics)
+		s.translations[p.Get("Slug")] = translations
+	}
 }
 
 // IsValidLang - is given language is valid in App scope
 func (app *Application) IsValidLang(lang string) bool {
-	_, isValid := app.translations[lang]
-	return isValid
+	return app.Snapshot().IsValidLang(lang)
 }
 
 // Print - output app highlights
 func (app *Application) Print() {
+	s := app.Snapshot()
+
 	log.Println(". . . . . . . . . . . . . . . . . . . . . . . . . . . . . . .")
 	log.Printf("%20s: %s", "ContentPath", app.ContentPath)
 	log.Printf("%20s: %s", "PublicPath", app.PublicPath)
 	log.Printf("%20s: %d", "Page count", app.PageCount())
-	log.Printf("%20s: %d", "Page (dir) count", len(app.slugPages.Filter(func(p *Page) bool { return p.IsDir() })))
-	log.Printf("%20s: %d", "Page (.md) count", len(app.slugPages.Filter(func(p *Page) bool { return !p.IsDir() })))
-	log.Printf("%20s: %d", "Collections", len(app.collections))
+	log.Printf("%20s: %d", "Page (dir) count", len(s.slugPages.Filter(func(p *Page) bool { return p.IsDir() })))
+	log.Printf("%20s: %d", "Page (.md) count", len(s.slugPages.Filter(func(p *Page) bool { return !p.IsDir() })))
+	log.Printf("%20s: %d", "Collections", len(s.collections))
 	log.Println(". . . . . . . . . . . . . . . . . . . . . . . . . . . . . . .")
 	log.Println()
 
 	// Print every language folder tree
-	for _, p := range app.Pages {
+	for _, p := range s.Pages {
 		p.PrintTree(0)
 	}
 	log.Println()
 
 	// Print linear pages by slugs
-	app.slugPages.Print()
+	s.slugPages.Print()
 
 	// Print linear pages by slugs
-	for ckey, c := range app.collections {
+	for ckey, c := range s.collections {
 		c.Print(ckey)
 		log.Println()
 	}
@@ -0,0 +1,374 @@
+package mango
+
+import (
+	"container/list"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CacheStats - rendered page cache counters
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+	Size    int64 // bytes of rendered pages in cache
+}
+
+// Rendered pages by url and language
+// Least recently used pages are dropped when cache is full
+type renderCache struct {
+	sync.Mutex
+
+	items map[string]*list.Element
+	lru   *list.List // front - most recently used
+	size  int64
+
+	// Incremented on every invalidation
+	// Pages rendered before it are not stored
+	gen uint64
+
+	hits   int64
+	misses int64
+}
+
+// One rendered page
+type cacheItem struct {
+	key  string
+	body []byte
+	etag string
+
+	// What page is made of (see pageDeps)
+	// nil - depends on everything
+	deps []string
+}
+
+// Dependency keys
+// p:{path}		-- page file or directory params
+// t:{path}		-- any page under directory
+// c:{ckey}:{item}	-- any page in collection item
+const (
+	depPath       = "p:"
+	depTree       = "t:"
+	depCollection = "c:"
+)
+
+func newRenderCache() *renderCache {
+	return &renderCache{
+		items: make(map[string]*list.Element, 0),
+		lru:   list.New(),
+	}
+}
+
+// Cached page by key
+func (c *renderCache) get(key string) *cacheItem {
+	c.Lock()
+	defer c.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil
+	}
+	c.hits++
+	c.lru.MoveToFront(el)
+	return el.Value.(*cacheItem)
+}
+
+// Current generation (take before rendering)
+func (c *renderCache) generation() uint64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.gen
+}
+
+// Store rendered page if nothing was invalidated since gen
+// Oldest pages are removed to fit in maxSize
+func (c *renderCache) add(item *cacheItem, gen uint64, maxSize int64) {
+	c.Lock()
+	defer c.Unlock()
+
+	if gen != c.gen || int64(len(item.body)) > maxSize {
+		return
+	}
+
+	if el, ok := c.items[item.key]; ok {
+		c.removeElement(el)
+	}
+	c.items[item.key] = c.lru.PushFront(item)
+	c.size += int64(len(item.body))
+
+	for c.size > maxSize {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+func (c *renderCache) removeElement(el *list.Element) {
+	item := el.Value.(*cacheItem)
+	c.lru.Remove(el)
+	delete(c.items, item.key)
+	c.size -= int64(len(item.body))
+}
+
+// Remove pages that depends on changed paths or collection items
+// Paths can be files or directories (everything under them is changed)
+func (c *renderCache) invalidate(paths []string, citems map[string]bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.gen++
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*cacheItem).isAffected(paths, citems) {
+			c.removeElement(el)
+		}
+		el = next
+	}
+}
+
+// Remove all pages
+func (c *renderCache) purge() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.gen++
+	c.items = make(map[string]*list.Element, 0)
+	c.lru.Init()
+	c.size = 0
+}
+
+func (c *renderCache) stats() CacheStats {
+	c.Lock()
+	defer c.Unlock()
+	return CacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.lru.Len(),
+		Size:    c.size,
+	}
+}
+
+// Does any of changes touch this page
+func (item *cacheItem) isAffected(paths []string, citems map[string]bool) bool {
+	if item.deps == nil {
+		return true
+	}
+
+	for _, dep := range item.deps {
+		switch {
+		case strings.HasPrefix(dep, depCollection):
+			if citems[dep] {
+				return true
+			}
+
+		case strings.HasPrefix(dep, depPath):
+			dpath := strings.TrimPrefix(dep, depPath)
+			for _, fpath := range paths {
+				if dpath == fpath || strings.HasPrefix(dpath, fpath+"/") {
+					return true
+				}
+			}
+
+		case strings.HasPrefix(dep, depTree):
+			dpath := strings.TrimPrefix(dep, depTree)
+			for _, fpath := range paths {
+				if dpath == fpath || strings.HasPrefix(dpath, fpath+"/") || strings.HasPrefix(fpath, dpath+"/") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Everything rendered page is made of:
+// page itself, parent, ContentFrom sources and collection peers
+// Returns nil for virtual pages (can show anything)
+func (app *Application) pageDeps(s *Snapshot, page *Page) []string {
+	path := page.Get("Path")
+	if path == "" || page.IsYes("IsVirtual") {
+		return nil
+	}
+
+	deps := []string{depPath + path}
+	if page.IsDir() {
+		// Content from sub-pages
+		deps = append(deps, depTree+path)
+	}
+	if page.Parent != nil && page.Parent.IsSet("Path") {
+		deps = append(deps, depPath+page.Parent.Get("Path"))
+	}
+
+	// Peers in same collection items
+	deps = append(deps, app.collectionDeps(s, page)...)
+
+	// Content from other pages
+	if cfrom := page.Get("ContentFrom"); cfrom != "" {
+		switch {
+		case strings.HasPrefix(cfrom, "http://") || strings.HasPrefix(cfrom, "https://"):
+			// Fetched on load only
+
+		case strings.HasPrefix(cfrom, "?"):
+			// Query can find any page
+			return nil
+
+		case strings.HasPrefix(cfrom, ".") || strings.HasPrefix(cfrom, "/"):
+			if fpath, err := filepath.Abs(cfrom); err == nil {
+				deps = append(deps, depPath+fpath)
+			}
+
+		case strings.Index(cfrom, ":") > 0:
+			arr := strings.SplitN(cfrom, ":", 2)
+			if ckey := app.collectionKey(s, arr[0]); ckey != "" {
+				deps = append(deps, depCollection+ckey+":"+strings.ToLower(strings.TrimSpace(arr[1])))
+			}
+
+		default:
+			if p2 := s.Page(cfrom); p2 != nil {
+				deps = append(deps, depPath+p2.Get("Path"))
+				if p2.IsDir() {
+					deps = append(deps, depTree+p2.Get("Path"))
+				}
+			}
+		}
+	}
+
+	return deps
+}
+
+// Collection items page belongs to as dependency keys
+func (app *Application) collectionDeps(s *Snapshot, page *Page) []string {
+	var deps []string
+	for _, ckey := range app.collectionKeys {
+		for _, citem := range page.Split(ckey, ",") {
+			deps = append(deps, depCollection+ckey+":"+strings.ToLower(citem))
+		}
+	}
+	return deps
+}
+
+// Collection key from config by any form of it (Tag, tags, Tags)
+func (app *Application) collectionKey(s *Snapshot, ckey string) string {
+	c := s.Collection(ckey)
+	if c == nil {
+		return ""
+	}
+	for _, ckey2 := range app.collectionKeys {
+		if s.collections[ckey2] == c {
+			return ckey2
+		}
+	}
+	return ""
+}
+
+// Drop cached pages that depends on changed paths
+// Pages found under paths in old and new snapshot gives collection changes
+// No paths - drop everything
+func (app *Application) invalidateCache(old, s *Snapshot, fpaths []string) {
+	if app.Server == nil {
+		return
+	}
+	cache := app.Server.cache
+	if len(fpaths) == 0 || old == nil {
+		cache.purge()
+		return
+	}
+
+	// Page added, removed or renamed
+	// Menus and page lists on any page can show it
+	if isPageSetChanged(old, s) {
+		cache.purge()
+		return
+	}
+
+	var paths []string
+	citems := make(map[string]bool, 0)
+	for _, fpath := range fpaths {
+		// Translations are used by every page
+		// and templates can link to any public file
+		if filepath.Base(fpath) == ".translations" || app.publicRelPath(fpath) != "" {
+			cache.purge()
+			return
+		}
+
+		// Pages near asset can link to it
+		if app.assetMode == assetsKeep || app.assetMode == assetsCopy {
+			if finfo, err := os.Stat(fpath); isAssetName(filepath.Base(fpath)) && (err != nil || !finfo.IsDir()) {
+				fpath = filepath.Dir(fpath)
+			}
+		}
+		paths = append(paths, fpath)
+
+		for _, snap := range []*Snapshot{old, s} {
+			for _, p := range app.pagesByPath(snap, fpath) {
+				for _, dep := range app.collectionDeps(snap, p) {
+					citems[dep] = true
+				}
+			}
+		}
+	}
+
+	cache.invalidate(paths, citems)
+}
+
+// Parse size from config
+// "32MB" --> 33554432, "512KB" --> 524288, "1000" --> 1000
+// Returns 0 for empty or invalid size
+func parseByteSize(s string) int64 {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for suffix, m := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(s, suffix) {
+			s, mult = strings.TrimSpace(strings.TrimSuffix(s, suffix)), m
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n * mult
+}
+
+// Compare pages of two snapshots by what menus and lists show
+func isPageSetChanged(old, s *Snapshot) bool {
+	sign := func(p *Page) string {
+		return strings.Join([]string{p.Get("Path"), p.Get("URL"), p.Get("Title"), p.Get("Label"), p.Get("Sort")}, "\x00")
+	}
+
+	old.slugPages.RLock()
+	defer old.slugPages.RUnlock()
+	s.slugPages.RLock()
+	defer s.slugPages.RUnlock()
+
+	if len(old.slugPages.m) != len(s.slugPages.m) {
+		return true
+	}
+	for key, p := range s.slugPages.m {
+		p0 := old.slugPages.m[key]
+		if p0 == nil || sign(p0) != sign(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheStats - rendered page cache counters
+func (srv *Server) CacheStats() CacheStats {
+	return srv.cache.stats()
+}
+
+// ReloadTemplates - parse templates again
+// All cached pages are dropped
+// Called by watcher on template changes. Safe while pages are served
+func (srv *Server) ReloadTemplates() error {
+	tmpl, err := srv.loadTemplates()
+	if err != nil {
+		return err
+	}
+	srv.setTemplates(tmpl)
+	srv.cache.purge()
+	return nil
+}
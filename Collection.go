@@ -55,6 +55,27 @@ func (c *Collection) Remove(key string) {
 	c.Unlock()
 }
 
+// RemovePage - remove page from every item
+// Items left without pages are removed too
+func (c *Collection) RemovePage(page *Page) {
+	c.Lock()
+	for key, pages := range c.m {
+		var _pages PageList
+		for _, p := range pages {
+			if p != page {
+				_pages = append(_pages, p)
+			}
+		}
+
+		if len(_pages) == 0 {
+			delete(c.m, key)
+		} else {
+			c.m[key] = _pages
+		}
+	}
+	c.Unlock()
+}
+
 // Make key lowercased and trimmed
 func (c *Collection) normalizeKey(key string) string {
 	key = strings.TrimSpace(key)
@@ -0,0 +1,206 @@
+package mango
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Severity - how serious is content problem
+type Severity string
+
+// Diagnostic severities
+const (
+	// Page is loaded but probably not as author wanted
+	SeverityWarning Severity = "warning"
+
+	// Something is missing (file, date, content)
+	SeverityError Severity = "error"
+)
+
+// Diagnostic - one problem found while loading content
+type Diagnostic struct {
+	// Absolute file path
+	Path string
+
+	// Line in file (0 if unknown)
+	Line int
+
+	Severity Severity
+	Message  string
+}
+
+// String - "path:line: severity: message"
+func (d Diagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", d.Path, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Path, d.Severity, d.Message)
+}
+
+// Diagnostics - collector of content problems
+// Every snapshot has its own, so problems always match loaded content.
+// Methods can be called on nil collector (problems are ignored)
+type Diagnostics struct {
+	sync.RWMutex
+	list []Diagnostic
+	seen map[Diagnostic]bool // same problem is reported once
+
+	// Schemas to check page params with (nil - params not checked)
+	schemas *schemaCache
+}
+
+// NewDiagnostics - create empty collector
+func NewDiagnostics() *Diagnostics {
+	return &Diagnostics{
+		seen: make(map[Diagnostic]bool, 0),
+	}
+}
+
+// Add problem
+func (ds *Diagnostics) Add(path string, line int, severity Severity, format string, args ...interface{}) {
+	if ds == nil {
+		return
+	}
+
+	d := Diagnostic{
+		Path:     path,
+		Line:     line,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	}
+
+	ds.Lock()
+	if !ds.seen[d] {
+		ds.seen[d] = true
+		ds.list = append(ds.list, d)
+	}
+	ds.Unlock()
+}
+
+// Add problem about page param
+// Line is found by looking for "Key:" in file
+func (ds *Diagnostics) addParam(path, key string, severity Severity, format string, args ...interface{}) {
+	if ds == nil {
+		return
+	}
+	ds.Add(path, paramLine(path, key), severity, format, args...)
+}
+
+// List - all problems sorted by path and line
+func (ds *Diagnostics) List() []Diagnostic {
+	if ds == nil {
+		return nil
+	}
+
+	ds.RLock()
+	list := append([]Diagnostic{}, ds.list...)
+	ds.RUnlock()
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Path != list[j].Path {
+			return list[i].Path < list[j].Path
+		}
+		return list[i].Line < list[j].Line
+	})
+	return list
+}
+
+// Errors - only problems with error severity
+func (ds *Diagnostics) Errors() []Diagnostic {
+	var list []Diagnostic
+	for _, d := range ds.List() {
+		if d.Severity == SeverityError {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+// Len - count of all problems
+func (ds *Diagnostics) Len() int {
+	if ds == nil {
+		return 0
+	}
+	ds.RLock()
+	defer ds.RUnlock()
+	return len(ds.list)
+}
+
+// Err - error describing all errors (nil if there are none)
+func (ds *Diagnostics) Err() error {
+	errs := ds.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(errs))
+	for _, d := range errs {
+		lines = append(lines, d.String())
+	}
+	return fmt.Errorf("%d content error(s):\n%s", len(errs), strings.Join(lines, "\n"))
+}
+
+// Copy of collector (partial reload)
+func (ds *Diagnostics) clone() *Diagnostics {
+	ds2 := NewDiagnostics()
+	if ds.schemas != nil {
+		// Schemas are read again (can be changed)
+		ds2.schemas = newSchemaCache(ds.schemas.root)
+	}
+	for _, d := range ds.List() {
+		ds2.list = append(ds2.list, d)
+		ds2.seen[d] = true
+	}
+	return ds2
+}
+
+// Remove problems of given file
+// withSub - also all files under given directory
+func (ds *Diagnostics) remove(fpath string, withSub bool) {
+	if ds == nil {
+		return
+	}
+
+	ds.Lock()
+	var list []Diagnostic
+	for _, d := range ds.list {
+		isMatch := d.Path == fpath || d.Path == fpath+"/.dir" ||
+			(withSub && strings.HasPrefix(d.Path, fpath+"/"))
+		if isMatch {
+			delete(ds.seen, d)
+			continue
+		}
+		list = append(list, d)
+	}
+	ds.list = list
+	ds.Unlock()
+}
+
+// Line number where param is set in file (0 if not found)
+// Used only when problem is found, so reading file again is ok
+func paramLine(fpath, key string) int {
+	buf, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		// Directory params are in .dir file
+		if buf, err = ioutil.ReadFile(fpath + "/.dir"); err != nil {
+			return 0
+		}
+	}
+
+	// Also "key: value" and "key = value" front matter
+	prefix := []byte(strings.ToLower(key))
+	for i, row := range bytes.Split(buf, []byte("\n")) {
+		row = bytes.ToLower(bytes.TrimSpace(row))
+		if !bytes.HasPrefix(row, prefix) {
+			continue
+		}
+		if rest := bytes.TrimSpace(row[len(prefix):]); len(rest) > 0 && (rest[0] == ':' || rest[0] == '=') {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,213 @@
+package mango
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Export - render every page to static HTML files under dir
+// Files from PublicPath are copied too, so dir can be served
+// by any static file server or object storage.
+// Uses same templates and FuncMap as running server
+func (srv *Server) Export(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	if _, err := srv.templates(); err != nil {
+		return err
+	}
+
+	// Same content for all export
+	s := srv.App.Snapshot()
+
+	// Public files by their urls
+	// (FileURL can have prefix and {Hash})
+	err = filepath.Walk(srv.App.PublicPath, func(fpath string, finfo os.FileInfo, err error) error {
+		if os.IsNotExist(err) && fpath == srv.App.PublicPath {
+			// Nothing public yet (dry run)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fpath == dir {
+			// Exporting inside public path
+			return filepath.SkipDir
+		}
+		if finfo.IsDir() {
+			return nil
+		}
+
+		rpath, _ := filepath.Rel(srv.App.PublicPath, fpath)
+		rpath = filepath.ToSlash(rpath)
+		if isGeneratedFile(rpath) && (rpath != highlightFname || srv.App.markdownOptions.Highlight) {
+			// Written from exported content below
+			// (own highlight.css is copied if highlighting is off)
+			return nil
+		}
+		if err := copyFile(fpath, fileURLToPath(dir, srv.App.fileURL(s, rpath))); err != nil {
+			return err
+		}
+
+		// "Naked" files are served from root too (robots.txt)
+		// and files in feeds directory (feeds/logo.png)
+		if strings.Index(rpath, "/") == -1 || strings.HasPrefix(rpath, feedsDir+"/") {
+			return copyFile(fpath, filepath.Join(dir, rpath))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sitemap, feeds and highlight.css of exported content
+	// (public path can have older ones or none if loaded with LoadContentDryRun)
+	srv.App.createSitemap(s, dir)
+	srv.App.createFeeds(s, dir)
+	srv.App.createHighlightCSS(s, dir)
+
+	// Assets still in content
+	// (kept there or not moved/copied yet by LoadContentDryRun)
+	for rpath, fpath := range s.assets {
+		if _, err := os.Stat(fpath); err != nil {
+			// Already moved to public path
+			continue
+		}
+		if srv.App.assetMode != assetsMove && srv.App.assetMode != "" {
+			rpath = assetsScope + "/" + rpath
+		}
+		dst := fileURLToPath(dir, srv.App.fileURL(s, rpath))
+		if _, err := os.Stat(dst); err == nil {
+			// Copied from public path
+			continue
+		}
+		if err := copyFile(fpath, dst); err != nil {
+			return err
+		}
+	}
+
+	// Index pages
+	// Default language and every language root
+	if err := srv.exportPage(dir, "/", srv.App.NewPage("", ""), "index"); err != nil {
+		return err
+	}
+	for _, p := range s.Pages {
+		lang := p.Get("Slug")
+		if err := srv.exportPage(dir, "/"+lang+"/", srv.App.NewPage(lang, ""), "index"); err != nil {
+			return err
+		}
+	}
+
+	// Every page by slug
+	// Sorted to export in the same order every time
+	// (.defaults pages are not real pages)
+	var pages PageList
+	s.slugPages.RLock()
+	for key, p := range s.slugPages.m {
+		if key[0] != '.' && !p.isTopLevel() {
+			pages = append(pages, p)
+		}
+	}
+	s.slugPages.RUnlock()
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Get("Slug") < pages[j].Get("Slug")
+	})
+
+	for _, p := range pages {
+		// Not "URL" param, because redirect pages have destination there
+		url := srv.App.pageURL(p)
+		if url == "" || url[0] != '/' || strings.Index(url, "{") >= 0 {
+			// Not local page url
+			continue
+		}
+
+		// No server to redirect, so html does it
+		if redirect := p.Get("Redirect"); redirect != "" {
+			if err := exportRedirect(dir, url, redirect); err != nil {
+				return err
+			}
+			continue
+		}
+
+		templateID := "one"
+		if p.IsDir() {
+			templateID = "group"
+		}
+		if err := srv.exportPage(dir, url, p, templateID); err != nil {
+			return err
+		}
+	}
+
+	// Most static hosts use "404.html" for not found pages
+	return srv.exportPage(dir, "/404.html", srv.App.NewPage("", "404"), "404")
+}
+
+// Is file under public path made from content
+// sitemap.xml(.gz), sitemap-en.xml.gz, highlight.css, feeds/en/rss.xml
+func isGeneratedFile(rpath string) bool {
+	if rpath == highlightFname || rpath == sitemapFname+".xml" || rpath == sitemapFname+".xml.gz" {
+		return true
+	}
+	if strings.HasPrefix(rpath, sitemapFname+"-") && strings.Index(rpath, ".xml") > 0 && strings.Index(rpath, "/") == -1 {
+		return true
+	}
+	if strings.HasPrefix(rpath, feedsDir+"/") {
+		_, isFeed := feedTypes[path.Base(rpath)]
+		return isFeed
+	}
+	return false
+}
+
+// Render one page to file under dir by given url
+func (srv *Server) exportPage(dir, url string, page *Page, templateID string) error {
+	var buf bytes.Buffer
+	if err := srv.Render(&buf, page, templateID); err != nil {
+		return fmt.Errorf("%s: %v", url, err)
+	}
+	return writeFile(urlToFilePath(dir, url), buf.Bytes())
+}
+
+// Write html page that redirects to given url
+func exportRedirect(dir, url, redirect string) error {
+	to := template.HTMLEscapeString(redirect)
+	html := "<!DOCTYPE html>\n<meta charset=\"utf-8\">\n" +
+		"<meta http-equiv=\"refresh\" content=\"0; url=" + to + "\">\n" +
+		"<link rel=\"canonical\" href=\"" + to + "\">\n" +
+		"<a href=\"" + to + "\">" + to + "</a>\n"
+	return writeFile(urlToFilePath(dir, url), []byte(html))
+}
+
+// Page url to file path
+// /en/hello.html	--> dir/en/hello.html
+// /en/hello		--> dir/en/hello/index.html
+// /en/				--> dir/en/index.html
+func urlToFilePath(dir, url string) string {
+	if strings.HasSuffix(url, "/") || filepath.Ext(url) == "" {
+		url = strings.TrimSuffix(url, "/") + "/index.html"
+	}
+	return filepath.Join(dir, filepath.FromSlash(url))
+}
+
+// File url to file path under dir
+// Query is not part of path: /css/style.css?v=1a2b3c
+func fileURLToPath(dir, url string) string {
+	url = strings.SplitN(url, "?", 2)[0]
+	return filepath.Join(dir, filepath.FromSlash(url))
+}
+
+// Create file with all parent directories
+func writeFile(fpath string, buf []byte) error {
+	if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fpath, buf, 0644)
+}
@@ -0,0 +1,442 @@
+package mango
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Feeds of latest pages (RSS, Atom, JSON Feed)
+//
+//	/feeds/en/rss.xml           -- language root
+//	/feeds/news/atom.xml        -- directory with "IsFeed: Yes" (by slug)
+//	/feeds/en/tags/dog/feed.json -- collection item in language
+//
+// Written to PublicPath/feeds/ on load and served dynamically by server
+const feedsDir = "feeds"
+
+// Items in feed (config "FeedSize" or directory page param overrides)
+const defaultFeedSize = 20
+
+// Feed files and their content types
+var feedTypes = map[string]string{
+	"rss.xml":   "application/rss+xml; charset=utf-8",
+	"atom.xml":  "application/atom+xml; charset=utf-8",
+	"feed.json": "application/feed+json; charset=utf-8",
+}
+
+// Latest pages of language, directory or collection item
+type feed struct {
+	path    string // en, news, en/tags/dog
+	title   string
+	lang    string
+	url     string // absolute url of page the feed is about
+	updated time.Time
+	pages   PageList
+}
+
+// Feed by path (nil if there is no such feed)
+func (app *Application) feed(s *Snapshot, path string) *feed {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch len(parts) {
+	case 1:
+		// Language root or directory
+		p := s.Page(parts[0])
+		if p == nil {
+			return nil
+		}
+		if p.Get("Level") == "0" || (p.IsDir() && p.IsYes("IsFeed")) {
+			size := p.GetInt("FeedSize")
+			if size <= 0 {
+				size = app.feedSize
+			}
+			lang := p.Get("Lang")
+			if p.Get("Level") == "0" {
+				lang = p.Get("Slug") // language root
+			}
+			return newFeed(s, path, p.Get("Title"), lang, p.AbsoluteURL(), subPages(p), size)
+		}
+
+	case 3:
+		// Collection item in language
+		lang, ckey, item := parts[0], parts[1], parts[2]
+		root := s.Page(lang)
+		if root == nil || root.Get("Level") != "0" {
+			return nil
+		}
+		for _, key := range app.collectionKeys {
+			if strings.ToLower(key) != ckey {
+				continue
+			}
+			for _, itemKey := range collectionItems(s.Collection(key)) {
+				if toSlug(itemKey) == item {
+					title := key + ": " + itemKey
+					return newFeed(s, path, title, lang, root.AbsoluteURL(), s.CollectionPages(key, itemKey), app.feedSize)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Paths of all feeds in snapshot
+func (app *Application) feedPaths(s *Snapshot) []string {
+	var paths []string
+	for _, root := range s.Pages {
+		lang := root.Get("Slug")
+		paths = append(paths, lang)
+
+		// Collection items with pages in this language
+		for _, key := range app.collectionKeys {
+			c := s.Collection(key)
+			for _, itemKey := range collectionItems(c) {
+				for _, p := range c.Get(itemKey) {
+					if p.Get("Lang") == lang {
+						paths = append(paths, lang+"/"+strings.ToLower(key)+"/"+toSlug(itemKey))
+						break
+					}
+				}
+			}
+		}
+	}
+
+	s.slugPages.RLock()
+	for slug, p := range s.slugPages.m {
+		if slug[0] != '.' && p.IsDir() && p.IsYes("IsFeed") && p.Get("Level") != "0" {
+			paths = append(paths, slug)
+		}
+	}
+	s.slugPages.RUnlock()
+
+	sort.Strings(paths)
+	return paths
+}
+
+// Item keys of collection (sorted)
+func collectionItems(c *Collection) []string {
+	if c == nil {
+		return nil
+	}
+	c.RLock()
+	defer c.RUnlock()
+
+	keys := make([]string, 0, len(c.m))
+	for key := range c.m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// All pages under page (sub-directories too)
+func subPages(page *Page) PageList {
+	var pages PageList
+	for _, p := range page.Pages {
+		pages = append(pages, p)
+		pages = append(pages, subPages(p)...)
+	}
+	return pages
+}
+
+// Feed of newest pages (not directories, redirects, unlisted)
+func newFeed(s *Snapshot, path, title, lang, link string, pages PageList, size int) *feed {
+	f := &feed{
+		path:  path,
+		title: title,
+		lang:  lang,
+		url:   link,
+	}
+	for _, p := range pages {
+		if p.IsDir() || p.IsSet("Redirect") || p.IsYes("IsUnlisted") || p.Get("Lang") != lang {
+			continue
+		}
+		f.pages = append(f.pages, p)
+	}
+
+	sort.SliceStable(f.pages, func(i, j int) bool {
+		return pageDate(f.pages[i]).After(pageDate(f.pages[j]))
+	})
+	if len(f.pages) > size {
+		f.pages = f.pages[:size]
+	}
+
+	f.updated = s.loadedAt
+	if len(f.pages) > 0 {
+		f.updated = pageDate(f.pages[0])
+	}
+	return f
+}
+
+// Date of page for feeds: "Date" param or file modification time
+func pageDate(p *Page) time.Time {
+	if dt := p.GetTime("Date"); !dt.IsZero() {
+		return dt
+	}
+	return p.ModTime()
+}
+
+// Write feed in format by file name (rss.xml, atom.xml, feed.json)
+func (f *feed) write(w io.Writer, domain, fname string) error {
+	feedURL := domain + "/" + feedsDir + "/" + f.path + "/" + fname
+
+	switch fname {
+	case "rss.xml":
+		rss := rssFeed{
+			Version: "2.0",
+			AtomNS:  "http://www.w3.org/2005/Atom",
+			Channel: rssChannel{
+				Title:         f.title,
+				Link:          f.url,
+				Description:   f.title,
+				Language:      f.lang,
+				LastBuildDate: f.updated.Format(time.RFC1123Z),
+				AtomLink:      rssAtomLink{Href: feedURL, Rel: "self", Type: "application/rss+xml"},
+			},
+		}
+		for _, p := range f.pages {
+			rss.Channel.Items = append(rss.Channel.Items, rssItem{
+				Title:       p.Get("Title"),
+				Link:        p.AbsoluteURL(),
+				GUID:        p.AbsoluteURL(),
+				PubDate:     pageDate(p).Format(time.RFC1123Z),
+				Description: p.Get("Summary"),
+			})
+		}
+		return writeXML(w, rss)
+
+	case "atom.xml":
+		author := domain
+		if u, err := url.Parse(domain); err == nil && u.Host != "" {
+			author = u.Host
+		}
+		atom := atomFeed{
+			Lang:    f.lang,
+			Title:   f.title,
+			ID:      feedURL,
+			Updated: f.updated.Format(time.RFC3339),
+			Links: []atomLink{
+				{Href: f.url, Rel: "alternate"},
+				{Href: feedURL, Rel: "self"},
+			},
+			Author: atomAuthor{Name: author},
+		}
+		for _, p := range f.pages {
+			atom.Entries = append(atom.Entries, atomEntry{
+				Title:   p.Get("Title"),
+				ID:      p.AbsoluteURL(),
+				Updated: pageDate(p).Format(time.RFC3339),
+				Link:    atomLink{Href: p.AbsoluteURL(), Rel: "alternate"},
+				Summary: p.Get("Summary"),
+			})
+		}
+		return writeXML(w, atom)
+
+	case "feed.json":
+		jf := jsonFeed{
+			Version:     "https://jsonfeed.org/version/1.1",
+			Title:       f.title,
+			HomePageURL: f.url,
+			FeedURL:     feedURL,
+			Language:    f.lang,
+			Items:       []jsonFeedItem{}, // not null
+		}
+		for _, p := range f.pages {
+			jf.Items = append(jf.Items, jsonFeedItem{
+				ID:            p.AbsoluteURL(),
+				URL:           p.AbsoluteURL(),
+				Title:         p.Get("Title"),
+				Summary:       p.Get("Summary"),
+				ContentText:   p.Get("Summary"),
+				DatePublished: pageDate(p).Format(time.RFC3339),
+			})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(jf)
+	}
+	return nil
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
+
+// Write all feeds under dir (public path or export)
+// Old feed files are removed (pages or collection items can be gone),
+// other files in feeds directory are left as they are
+func (app *Application) createFeeds(s *Snapshot, dir string) {
+	dir = dir + "/" + feedsDir
+	removeFeedFiles(dir)
+
+	for _, path := range app.feedPaths(s) {
+		f := app.feed(s, path)
+		if f == nil {
+			continue
+		}
+		os.MkdirAll(filepath.Join(dir, path), 0755)
+		for fname := range feedTypes {
+			fpath := filepath.Join(dir, path, fname)
+			file, err := os.Create(fpath)
+			if err != nil {
+				s.diagnostics.Add(dir, 0, SeverityError, "can't write feed: %v", err)
+				return
+			}
+			err = f.write(file, app.Domain, fname)
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				s.diagnostics.Add(fpath, 0, SeverityError, "can't write feed: %v", err)
+			}
+		}
+	}
+}
+
+// Remove feed files (rss.xml, atom.xml, feed.json) under dir
+// and directories left empty
+func removeFeedFiles(dir string) {
+	var dirs []string
+	filepath.Walk(dir, func(fpath string, finfo os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if finfo.IsDir() {
+			dirs = append(dirs, fpath)
+		} else if _, isFeed := feedTypes[finfo.Name()]; isFeed {
+			os.Remove(fpath)
+		}
+		return nil
+	})
+
+	// Deepest first, not empty ones are not removed
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
+
+// Feed and its file name by path in feeds directory: en/rss.xml
+// Returns nil if there is no such feed
+func (app *Application) feedFile(s *Snapshot, path string) (*feed, string) {
+	pos := strings.LastIndex(path, "/")
+	if pos <= 0 {
+		return nil, ""
+	}
+	fname := path[pos+1:]
+	if _, isFeed := feedTypes[fname]; !isFeed {
+		return nil, ""
+	}
+	return app.feed(s, path[:pos]), fname
+}
+
+// Route matcher for feeds
+// Other files under /feeds/ are left to file routes
+func (srv *Server) isFeedRequest(r *http.Request, rm *mux.RouteMatch) bool {
+	path := strings.TrimPrefix(r.URL.Path, "/"+feedsDir+"/")
+	f, _ := srv.App.feedFile(srv.App.Snapshot(), path)
+	return f != nil
+}
+
+// RunFeed - handler for feeds: /feeds/en/rss.xml
+func (srv *Server) RunFeed(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	f, fname := srv.App.feedFile(srv.App.Snapshot(), vars["Feed"])
+	if f == nil {
+		srv.Run404(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", feedTypes[fname])
+	f.write(w, srv.App.Domain, fname)
+}
+
+// ** Feed formats
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	Language      string      `xml:"language,omitempty"`
+	LastBuildDate string      `xml:"lastBuildDate"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	Items         []rssItem   `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang    string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Link    atomLink `xml:"link"`
+	Summary string   `xml:"summary,omitempty"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Language    string         `json:"language,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	Summary       string `json:"summary,omitempty"`
+	ContentText   string `json:"content_text"`
+	DatePublished string `json:"date_published"`
+}
@@ -0,0 +1,608 @@
+package mango
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Front matter formats (besides mango "Key: Value" header)
+//
+//	---			+++
+//	title: Hello		title = "Hello"
+//	tags: [dog, cat]	tags = ["dog", "cat"]
+//	---			+++
+//	Content			Content
+const (
+	frontYAML = "---"
+	frontTOML = "+++"
+)
+
+// Split file to front matter and content
+// Returns "" format if file has no (closed) YAML or TOML front matter
+// Block of other lines is content (--- is also markdown horizontal rule)
+func splitFrontMatter(buf []byte) (string, []byte, []byte) {
+	buf = bytes.Replace(buf, []byte("\r\n"), []byte("\n"), -1)
+
+	for _, fence := range []string{frontYAML, frontTOML} {
+		if !bytes.HasPrefix(buf, []byte(fence+"\n")) {
+			continue
+		}
+
+		// Closing fence on its own line
+		rest := buf[len(fence)+1:]
+		offset := 0
+		for _, line := range bytes.SplitAfter(rest, []byte("\n")) {
+			if string(bytes.TrimRight(line, " \t\n")) == fence {
+				if !isFrontMatter(fence, rest[:offset]) {
+					break
+				}
+				return fence, rest[:offset], rest[offset+len(line):]
+			}
+			offset += len(line)
+		}
+	}
+	return "", nil, nil
+}
+
+// Front matter starts with "key: value" (YAML), "key = value" or [table] (TOML)
+// Comments and empty lines are skipped, empty block is front matter too
+func isFrontMatter(fence string, buf []byte) bool {
+	sep := ":"
+	if fence == frontTOML {
+		sep = "="
+	}
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if fence == frontTOML && line[0] == '[' {
+			return true
+		}
+		i := strings.Index(line, sep)
+		if i <= 0 {
+			return false
+		}
+		key := strings.Trim(strings.TrimSpace(line[:i]), "\"'")
+		return key != "" && !strings.ContainsAny(key, " \t")
+	}
+	return true
+}
+
+// Params from YAML or TOML front matter
+// Nested keys are flattened with dots (Author.Name, Gallery.0.Src),
+// lists of values joined with ", " (Tags: dog, cat)
+func frontMatterToParams(format string, buf []byte, fpath string, diag *Diagnostics) map[string]string {
+	fp := &frontParams{
+		params: map[string]string{"SourceParams": ""},
+		fpath:  fpath,
+		diag:   diag,
+		lineNr: 2, // after opening fence
+	}
+
+	lines := strings.Split(string(buf), "\n")
+	switch format {
+	case frontYAML:
+		y := &yamlParser{frontParams: fp, lines: lines}
+		y.parseMap("", 0)
+	case frontTOML:
+		fp.parseTOML(lines)
+	}
+
+	labelToSlug(fp.params)
+	return fp.params
+}
+
+// Flat params collected from nested front matter
+type frontParams struct {
+	params map[string]string
+	fpath  string
+	diag   *Diagnostics
+	lineNr int // of first parsed line in file
+}
+
+// Problem on parsed line
+func (fp *frontParams) warn(i int, format string, args ...interface{}) {
+	fp.diag.Add(fp.fpath, fp.lineNr+i, SeverityWarning, format, args...)
+}
+
+func (fp *frontParams) set(key, val string) {
+	if strings.ContainsAny(key, " \t") {
+		fp.diag.Add(fp.fpath, 0, SeverityWarning, "param key %q can't contain spaces", key)
+		return
+	}
+	if k := strings.TrimLeft(key, "+-!"); k == "" || strings.Contains("."+k+".", "..") {
+		fp.diag.Add(fp.fpath, 0, SeverityWarning, "param key %q has empty part", key)
+		return
+	}
+	if _, ok := fp.params[key]; !ok {
+		fp.params["SourceParams"] += key + ", "
+	}
+	fp.params[key] = val
+}
+
+// Inline value: scalar, [list] or {table}
+// sep is key-value separator in tables (":" for YAML, "=" for TOML)
+func (fp *frontParams) setFlow(key, s string, sep byte) {
+	s = strings.TrimSpace(s)
+
+	switch {
+	case strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"):
+		items := splitFlow(s[1 : len(s)-1])
+		isScalar := true
+		for _, item := range items {
+			if item[0] == '[' || item[0] == '{' {
+				isScalar = false
+			}
+		}
+		if isScalar {
+			vals := make([]string, 0, len(items))
+			for _, item := range items {
+				if val := frontValue(item); val != "" {
+					vals = append(vals, val)
+				}
+			}
+			fp.set(key, strings.Join(vals, ", "))
+			return
+		}
+		for i, item := range items {
+			fp.setFlow(key+"."+strconv.Itoa(i), item, sep)
+		}
+
+	case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+		for _, item := range splitFlow(s[1 : len(s)-1]) {
+			k, v, ok := splitPair(item, sep)
+			if !ok {
+				continue
+			}
+			fp.setFlow(key+"."+frontKey(k, sep), v, sep)
+		}
+
+	default:
+		fp.set(key, frontValue(s))
+	}
+}
+
+// ** YAML (block maps, lists, flow values, | and > text)
+
+type yamlParser struct {
+	*frontParams
+	lines []string
+	pos   int
+}
+
+// Skip blank and comment lines
+// Returns indent of next line or false on end
+func (y *yamlParser) next() (int, bool) {
+	for ; y.pos < len(y.lines); y.pos++ {
+		line := strings.TrimSpace(stripComment(y.lines[y.pos]))
+		if line != "" {
+			return indentOf(y.lines[y.pos]), true
+		}
+	}
+	return 0, false
+}
+
+// Current line without indent and comment
+func (y *yamlParser) line() string {
+	return strings.TrimSpace(stripComment(y.lines[y.pos]))
+}
+
+// Keys on same indent
+func (y *yamlParser) parseMap(prefix string, indent int) {
+	for {
+		ind, ok := y.next()
+		if !ok || ind < indent {
+			return
+		}
+		line, nr := y.line(), y.pos
+		y.pos++
+
+		if ind > indent || isListItem(line) {
+			y.warn(nr, "unexpected yaml line %q", line)
+			continue
+		}
+		k, v, ok := splitPair(line, ':')
+		if !ok {
+			y.warn(nr, "malformed line %q (expected \"key: value\")", line)
+			continue
+		}
+		y.parseValue(joinKey(prefix, frontKey(k, ':')), v, ind)
+	}
+}
+
+// Value of key (on same line or in lines below)
+func (y *yamlParser) parseValue(key, v string, indent int) {
+	switch {
+	case v == "":
+		// Nested map or list below
+		ind, ok := y.next()
+		switch {
+		case ok && ind >= indent && isListItem(y.line()):
+			y.parseList(key, ind)
+		case ok && ind > indent:
+			y.parseMap(key, ind)
+		default:
+			y.set(key, "")
+		}
+
+	case v[0] == '|' || v[0] == '>':
+		y.set(key, y.parseText(indent, v[0] == '>'))
+
+	default:
+		// Flow value can continue in next lines
+		for flowDepth(v) > 0 && y.pos < len(y.lines) {
+			v += " " + y.line()
+			y.pos++
+		}
+		// Plain text can continue on more indented lines
+		for {
+			ind, ok := y.next()
+			if !ok || ind <= indent || v[0] == '[' || v[0] == '{' {
+				break
+			}
+			v += " " + y.line()
+			y.pos++
+		}
+		y.setFlow(key, v, ':')
+	}
+}
+
+// "- item" lines on same indent
+// List of plain values is joined: "dog, cat"
+// otherwise items are numbered: Gallery.0.Src
+func (y *yamlParser) parseList(key string, indent int) {
+	vals := make(map[int]string, 0) // plain items
+	n := 0
+	for ; ; n++ {
+		ind, ok := y.next()
+		if !ok || ind != indent || !isListItem(y.line()) {
+			break
+		}
+		raw := y.lines[y.pos]
+		rest := strings.TrimSpace(stripComment(raw))[1:]
+		col := indent + 1 + indentOf(rest) // where item content starts
+		rest = strings.TrimSpace(rest)
+		ikey := key + "." + strconv.Itoa(n)
+
+		switch {
+		case rest == "":
+			// Item is block below
+			y.pos++
+			ind2, ok := y.next()
+			switch {
+			case !ok || ind2 <= indent:
+				vals[n] = ""
+			case isListItem(y.line()):
+				y.parseList(ikey, ind2)
+			default:
+				y.parseMap(ikey, ind2)
+			}
+
+		case isListItem(rest) || isYAMLPair(rest):
+			// "- - a" or "- src: a.jpg" continues as block at item column
+			y.lines[y.pos] = strings.Repeat(" ", col) + strings.TrimSpace(raw[strings.Index(raw, "-")+1:])
+			if isListItem(rest) {
+				y.parseList(ikey, col)
+			} else {
+				y.parseMap(ikey, col)
+			}
+
+		case rest[0] == '[' || rest[0] == '{':
+			y.pos++
+			y.setFlow(ikey, rest, ':')
+
+		default:
+			y.pos++
+			vals[n] = frontValue(rest)
+		}
+	}
+
+	// Plain list - one param
+	if len(vals) == n {
+		var items []string
+		for i := 0; i < n; i++ {
+			if vals[i] != "" {
+				items = append(items, vals[i])
+			}
+		}
+		y.set(key, strings.Join(items, ", "))
+		return
+	}
+	for i := 0; i < n; i++ {
+		if val, ok := vals[i]; ok {
+			y.set(key+"."+strconv.Itoa(i), val)
+		}
+	}
+}
+
+// Multiline text after | (newlines kept) or > (folded to spaces)
+func (y *yamlParser) parseText(indent int, isFolded bool) string {
+	var lines []string
+	textIndent := -1
+	for ; y.pos < len(y.lines); y.pos++ {
+		line := y.lines[y.pos]
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		ind := indentOf(line)
+		if ind <= indent {
+			break
+		}
+		if textIndent < 0 || ind < textIndent {
+			textIndent = ind
+		}
+		lines = append(lines, strings.TrimRight(line[textIndent:], " \t"))
+	}
+
+	if !isFolded {
+		return strings.TrimSpace(strings.Join(lines, "\n"))
+	}
+
+	// Folded: single newlines become spaces, empty lines newlines
+	var buf strings.Builder
+	for i, line := range lines {
+		switch {
+		case line == "":
+			buf.WriteString("\n")
+		case i > 0 && lines[i-1] != "":
+			buf.WriteString(" " + line)
+		default:
+			buf.WriteString(line)
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// ** TOML (key = value, [table], [[array of tables]])
+
+func (fp *frontParams) parseTOML(lines []string) {
+	prefix := ""
+	tables := make(map[string]int, 0) // [[name]] counts
+
+LINES:
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(stripComment(lines[i]))
+
+		switch {
+		case line == "":
+			continue
+
+		case strings.HasPrefix(line, "[["):
+			name := frontKey(strings.Trim(line, "[] "), '=')
+			prefix = name + "." + strconv.Itoa(tables[name])
+			tables[name]++
+
+		case strings.HasPrefix(line, "["):
+			prefix = frontKey(strings.Trim(line, "[] "), '=')
+
+		default:
+			k, v, ok := splitPair(line, '=')
+			if !ok {
+				fp.warn(i, "malformed line %q (expected \"key = value\")", line)
+				continue
+			}
+			key := joinKey(prefix, frontKey(k, '='))
+
+			// Multiline strings
+			for _, q := range []string{`"""`, `'''`} {
+				if !strings.HasPrefix(v, q) {
+					continue
+				}
+				text := strings.SplitN(lines[i], q, 2)[1]
+				for !strings.Contains(text, q) && i+1 < len(lines) {
+					i++
+					text += "\n" + lines[i]
+				}
+				if pos := strings.Index(text, q); pos >= 0 {
+					text = text[:pos]
+				}
+				fp.set(key, strings.TrimSpace(text))
+				continue LINES
+			}
+
+			// Arrays can continue in next lines
+			for flowDepth(v) > 0 && i+1 < len(lines) {
+				i++
+				v += " " + strings.TrimSpace(stripComment(lines[i]))
+			}
+			fp.setFlow(key, v, '=')
+		}
+	}
+}
+
+// ** Helpers
+
+// Param key in mango style: title --> Title
+// Dotted keys are nested: author.name --> Author.Name
+func frontKey(k string, sep byte) string {
+	var parts []string
+	if sep == '=' {
+		// TOML dotted key
+		parts = splitQuoted(k, '.')
+	} else {
+		parts = []string{k}
+	}
+	for i, part := range parts {
+		part = unquoteValue(strings.TrimSpace(part))
+		op := part[:len(part)-len(strings.TrimLeft(part, "+-!"))] // +tags --> +Tags
+		if len(part) == len(op) {
+			// Empty part stays empty (set reports it)
+			parts[i] = part
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(part[len(op):])
+		parts[i] = op + string(unicode.ToUpper(r)) + part[len(op)+size:]
+	}
+	return strings.Join(parts, ".")
+}
+
+// Value in mango style
+// Quotes removed, true/false --> Yes/No, null --> ""
+func frontValue(s string) string {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "true":
+		return _Yes
+	case "false":
+		return _No
+	case "null", "~":
+		return ""
+	}
+	return unquoteValue(s)
+}
+
+// "text" (with escapes) or 'text'
+func unquoteValue(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	switch {
+	case s[0] == '"' && s[len(s)-1] == '"':
+		if val, err := strconv.Unquote(s); err == nil {
+			return val
+		}
+		return s[1 : len(s)-1]
+	case s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1)
+	}
+	return s
+}
+
+// Split key and value by first separator outside quotes
+// YAML separator must be followed by space ("url: http://..")
+func splitPair(s string, sep byte) (string, string, bool) {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			if sep == ':' && i+1 < len(s) && s[i+1] != ' ' && s[i+1] != '\t' {
+				continue
+			}
+			k := strings.TrimSpace(s[:i])
+			return k, strings.TrimSpace(s[i+1:]), k != ""
+		}
+	}
+	return "", "", false
+}
+
+// Split by separator outside quotes and brackets
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quote := byte(0)
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Items of inline list or table (empty items skipped)
+func splitFlow(s string) []string {
+	var items []string
+	for _, item := range splitQuoted(s, ',') {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Not closed brackets in inline value
+func flowDepth(s string) int {
+	depth := 0
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// Remove # comment (not inside quotes)
+func stripComment(s string) string {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// "- item" or "-"
+func isListItem(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ")
+}
+
+// "key: value" (not quoted text or inline value)
+func isYAMLPair(s string) bool {
+	if s[0] == '"' || s[0] == '\'' || s[0] == '[' || s[0] == '{' {
+		return false
+	}
+	_, _, ok := splitPair(s, ':')
+	return ok
+}
+
+// Leading spaces (tab counts as one)
+func indentOf(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " \t"))
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -14,6 +14,7 @@ var (
 	defaultFuncMap = template.FuncMap{
 		"T":         T,
 		"Get":       tGet,
+		"GetNested": tGetNested,
 		"Set":       tSet,
 		"Content":   tContent,
 		"Page":      tPage,
@@ -24,27 +25,32 @@ var (
 		// "PageURL":        PageURL,
 		// "FileURL":        FileURL,
 		// "GetParams":      GetParams,
-		"ToTags":      tParseToTags,
-		"CurrentYear": tCurrentYear,
-		"DateFormat":  tDateFormat,
-		"FileURL":     tFileURL,
-		"Print":       tPrint,
-		"Loop":        tLoop,
-		"Split":       tSplitToSlice,
+		"ToTags":        tParseToTags,
+		"CurrentYear":   tCurrentYear,
+		"DateFormat":    tDateFormat,
+		"FileURL":       tFileURL,
+		"ImageURL":      tImageURL,
+		"SearchResults": tSearchResults,
+		"Query":         tQuery,
+		"Print":         tPrint,
+		"Loop":          tLoop,
+		"Split":         tSplitToSlice,
+		"TOC":           tTOC,
+		"Summary":       tSummary,
+		"Description":   tDescription,
+		"WordCount":     tWordCount,
+		"ReadingTime":   tReadingTime,
 	}
 )
 
 // T - Translate string to given language
 func T(page *Page, s string) string {
-	if page.App == nil {
+	snap := page.snapshot()
+	if snap == nil {
 		return s // cant translate w/o app
 	}
 
-	if translated, ok := page.App.translations[page.Get("Lang")][s]; ok {
-		return translated
-	}
-
-	return s
+	return snap.Translate(page.Get("Lang"), s)
 }
 
 // Get param from Page or params map
@@ -55,11 +61,30 @@ func tGet(page interface{}, key string) string {
 		return page.(*Page).Get(key)
 	case map[string]string:
 		return page.(map[string]string)[key]
+	case map[string]interface{}, []interface{}:
+		// Item of nested param
+		if v := pathValue(page, key); v != nil {
+			return valueToString(v)
+		}
 	}
 
 	return ""
 }
 
+// Get nested param from Page or nested param item
+//
+//	{{ range GetNested $Page "Gallery" }}{{ Get . "Src" }}{{ end }}
+func tGetNested(page interface{}, key string) interface{} {
+	switch page.(type) {
+	case *Page:
+		return page.(*Page).GetNested(key)
+	case map[string]string, map[string]interface{}, []interface{}:
+		return pathValue(page, key)
+	}
+
+	return nil
+}
+
 // Set param for Page or params map
 // Use to change/add new param inside template
 func tSet(page interface{}, key string, val interface{}) string {
@@ -81,9 +106,10 @@ func tContent(page *Page) template.HTML {
 // Get Page by given slug
 // Give Application context
 func tPage(page *Page, slug string) *Page {
-	p := page.App.Page(page.Get("Lang") + "-" + slug)
+	s := page.snapshot()
+	p := s.Page(page.Get("Lang") + "-" + slug)
 	if p == nil {
-		p = page.App.Page(slug)
+		p = s.Page(slug)
 	}
 	return p
 }
@@ -96,8 +122,19 @@ func tHTML(args ...interface{}) template.HTML {
 
 // Markdown To HTML - Parse string (markdown) to html
 // used in tmeplates
+// With page as first arg app renderer and page markdown options are used:
+//
+//	MdToHTML $Page .Description
 func tMdToHTML(args ...interface{}) template.HTML {
-	return template.HTML(parseMarkdown([]byte(fmt.Sprintf("%s", args...))))
+	var app *Application // nil - default renderer
+	var params map[string]string
+	if len(args) > 1 {
+		if page, ok := args[0].(*Page); ok && page != nil {
+			app, params = page.App, page.Params()
+			args = args[1:]
+		}
+	}
+	return template.HTML(app.markdownToHTML([]byte(fmt.Sprintf("%s", args...)), params))
 }
 
 // Slice - used in template to Slice
@@ -185,8 +222,8 @@ func tParseToTags(page *Page, codeLang string, params ...string) template.HTML {
 			var arr []string
 			for _, slug := range slugs {
 				slug = strings.TrimSpace(slug)
-				if p := page.App.Page(slug); p != nil {
-					if lvl, _ := strconv.Atoi(p.Get("Level")); lvl >= 2 {
+				if p := page.snapshot().Page(slug); p != nil {
+					if p.GetInt("Level") >= 2 {
 						// Skip root levels
 						// If need root levels add it in html by yourself
 						arr = append(arr, "<a href=\""+p.Get("URL")+"\" title=\""+p.Get("Title")+"\">"+p.Get("Title")+"</a>")
@@ -209,13 +246,40 @@ func tCurrentYear() int {
 }
 
 func tFileURL(page *Page, parts ...string) string {
-	// get prefix
-	arr := strings.SplitN(page.App.URLTemplates["File"], "{File", 2)
-	prefix := arr[0]
-
 	// construct based on url file template
-	url := prefix + strings.Join(parts, "/")
-	return path.Clean(url)
+	// (with content hash if FileURL have {Hash})
+	rpath := path.Clean("/" + strings.Join(parts, "/"))
+	return page.App.fileURL(page.snapshot(), rpath)
+}
+
+// Pages found by query
+// {{ range Query $Page "Tags:dog sort:-ModTime limit:5" }}
+func tQuery(page *Page, query string) PageList {
+	return page.snapshot().Query(query)
+}
+
+// Search results (with snippets) shown on search page
+// Same pages as in page.Pages
+// {{ range SearchResults $Page }} {{ .Page.Get "Title" }} {{ .Snippet }} {{ end }}
+func tSearchResults(page *Page) []SearchResult {
+	results := page.snapshot().SearchResults(page.Get("Lang"), page.Get("SearchTerm"))
+	from := page.GetInt("PFrom")
+	to := page.GetInt("PTo")
+	if from < 0 || to > len(results) || from > to {
+		return nil
+	}
+	return results[from:to]
+}
+
+// URL of image resized to given width
+// Image path is relative to PublicPath (as in FileURL)
+// {{ ImageURL $Page "images/logo.png" 640 }} --> /images/logo-640w.png
+func tImageURL(page *Page, fpath string, width int) string {
+	rpath := strings.TrimPrefix(path.Clean("/"+fpath), "/")
+	if page.App == nil {
+		return "/" + rpath
+	}
+	return page.App.imageURL(page.snapshot(), rpath, width)
 }
 
 func tDateFormat(layout, s string) string {
@@ -261,3 +325,39 @@ func tLoop(s interface{}) []int {
 func tSplitToSlice(s, sep string) []string {
 	return strings.Split(s, sep)
 }
+
+// Table of contents of page content
+// Levels from args or page params "TOCMinLevel", "TOCMaxLevel":
+//
+//	TOC $Page     -- all headings
+//	TOC $Page 2 3 -- only h2, h3
+func tTOC(page *Page, levels ...int) template.HTML {
+	minLevel, maxLevel := page.GetInt("TOCMinLevel"), page.GetInt("TOCMaxLevel")
+	if len(levels) > 0 {
+		minLevel = levels[0]
+	}
+	if len(levels) > 1 {
+		maxLevel = levels[1]
+	}
+	return page.TOC(minLevel, maxLevel)
+}
+
+// Summary of page content (HTML before <!--more--> or "Summary" param)
+func tSummary(page *Page) template.HTML {
+	return page.Summary()
+}
+
+// Description for meta tags ("Description" param or cut from summary)
+func tDescription(page *Page) string {
+	return page.Description()
+}
+
+// Words in page content
+func tWordCount(page *Page) int {
+	return page.WordCount()
+}
+
+// Minutes to read page content
+func tReadingTime(page *Page) int {
+	return page.ReadingTime()
+}
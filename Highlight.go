@@ -0,0 +1,158 @@
+package mango
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// Highlighted code has CSS classes, colors are in PublicPath/highlight.css
+const highlightFname = "highlight.css"
+
+// Style used when not set in config
+const defaultHighlightStyle = "github"
+
+// Fenced code block language and attributes
+//
+//	```go {linenos=true, hl_lines="2 4-5", linenostart=10}
+//
+// hl_lines are counted from first line of block (not from linenostart)
+type codeInfo struct {
+	lang        string
+	lineNumbers string // "" - off, "inline" or "table"
+	hlLines     [][2]int
+	lineStart   int
+}
+
+// Parse fenced code info string
+// Line numbers are on by default if isLineNumbers
+func parseCodeInfo(info string, isLineNumbers bool) codeInfo {
+	ci := codeInfo{lineStart: 1}
+	if isLineNumbers {
+		ci.lineNumbers = "inline"
+	}
+
+	// "go {attrs}", "{.go attrs}", "go attrs"
+	info = strings.NewReplacer("{", " ", "}", " ", ",", " ").Replace(info)
+	for i, field := range splitQuery(info) {
+		pos := strings.Index(field, "=")
+		if pos < 0 {
+			if i == 0 {
+				ci.lang = strings.TrimPrefix(strings.TrimPrefix(field, "."), "language-")
+			}
+			continue
+		}
+
+		key, val := strings.ToLower(field[:pos]), unquoteValue(field[pos+1:])
+		switch key {
+		case "linenos":
+			switch strings.ToLower(val) {
+			case "table", "inline":
+				ci.lineNumbers = strings.ToLower(val)
+			default:
+				if b, err := parseBool(val); err == nil && !b {
+					ci.lineNumbers = ""
+				} else if ci.lineNumbers == "" {
+					ci.lineNumbers = "inline"
+				}
+			}
+		case "hl_lines":
+			ci.hlLines = parseLineRanges(val)
+		case "linenostart":
+			if n, err := strconv.Atoi(val); err == nil {
+				ci.lineStart = n
+			}
+		}
+	}
+	return ci
+}
+
+// Line numbers and ranges: "2 4-5" --> [2 2] [4 5]
+func parseLineRanges(val string) [][2]int {
+	var ranges [][2]int
+	for _, item := range strings.Fields(strings.Replace(val, ",", " ", -1)) {
+		arr := strings.SplitN(item, "-", 2)
+		from, err := strconv.Atoi(arr[0])
+		if err != nil {
+			continue
+		}
+		to := from
+		if len(arr) == 2 {
+			if to, err = strconv.Atoi(arr[1]); err != nil || to < from {
+				continue
+			}
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+	return ranges
+}
+
+// Write highlighted code
+// Returns false if language is not given or not known
+func highlightCode(w io.Writer, code []byte, info string, opts MarkdownOptions) bool {
+	ci := parseCodeInfo(info, opts.LineNumbers)
+	if ci.lang == "" {
+		return false
+	}
+	lexer := lexers.Get(ci.lang)
+	if lexer == nil {
+		return false
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, string(code))
+	if err != nil {
+		return false
+	}
+
+	// hl_lines are counted from first line of block
+	for i := range ci.hlLines {
+		ci.hlLines[i][0] += ci.lineStart - 1
+		ci.hlLines[i][1] += ci.lineStart - 1
+	}
+
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.WithLineNumbers(ci.lineNumbers != ""),
+		chromahtml.LineNumbersInTable(ci.lineNumbers == "table"),
+		chromahtml.BaseLineNumber(ci.lineStart),
+		chromahtml.HighlightLines(ci.hlLines),
+	)
+
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, styles.Fallback, iterator); err != nil {
+		return false
+	}
+	w.Write(buf.Bytes())
+	return true
+}
+
+// Write highlight.css under dir (public path or export)
+// Style from config "HighlightStyle: monokai"
+// Not written if highlighting is turned off in config
+func (app *Application) createHighlightCSS(s *Snapshot, dir string) {
+	if !app.markdownOptions.Highlight {
+		return
+	}
+
+	name := app.highlightStyle
+	if name == "" {
+		name = defaultHighlightStyle
+	}
+
+	var buf bytes.Buffer
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+	if err := formatter.WriteCSS(&buf, styles.Get(name)); err != nil {
+		s.diagnostics.Add(dir, 0, SeverityError, "can't write highlight css: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(dir+"/"+highlightFname, buf.Bytes(), 0644); err != nil {
+		s.diagnostics.Add(dir, 0, SeverityError, "can't write highlight css: %v", err)
+	}
+}
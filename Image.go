@@ -0,0 +1,292 @@
+package mango
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Image variants are made only for these formats
+// (gif can be animated, svg is vector)
+var resizableImages = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+}
+
+// Quality for resized jpeg images
+const imageQuality = 85
+
+// Is given file resizable image
+func isResizable(fpath string) bool {
+	return resizableImages[strings.ToLower(filepath.Ext(fpath))]
+}
+
+// Is given file jpeg image
+func isJPEG(fpath string) bool {
+	ext := strings.ToLower(filepath.Ext(fpath))
+	return ext == ".jpg" || ext == ".jpeg"
+}
+
+// Path of image variant with given width
+// /public/images/logo.png --> /public/images/logo-640w.png
+func imageVariantPath(fpath string, width int) string {
+	ext := filepath.Ext(fpath)
+	return strings.TrimSuffix(fpath, ext) + "-" + strconv.Itoa(width) + "w" + ext
+}
+
+// Process image from asset step
+// src - original image file
+// dst - where image is (or would be) in public path
+// Variants are written next to dst for every configured width
+// smaller than image itself. Existing fresh variants are not made again
+func (app *Application) processImage(s *Snapshot, src, dst string) {
+	if s.isDryRun || !isResizable(src) {
+		return
+	}
+
+	// Public copy without metadata (GPS, camera..)
+	if app.isStripEXIF && isJPEG(dst) {
+		if err := stripFileEXIF(dst); err != nil && !os.IsNotExist(err) {
+			s.diagnostics.Add(src, 0, SeverityWarning, "can't strip EXIF: %v", err)
+		}
+	}
+
+	for _, width := range app.imageWidths {
+		if err := makeImageVariant(src, dst, width); err != nil {
+			s.diagnostics.Add(src, 0, SeverityWarning, "can't resize image: %v", err)
+			return
+		}
+	}
+}
+
+// Create resized copy of src next to dst
+// Nothing is done if image is not wider than width
+func makeImageVariant(src, dst string, width int) error {
+	vpath := imageVariantPath(dst, width)
+	if isFresh(src, vpath) {
+		return nil
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return err
+	}
+	if cfg.Width <= width {
+		return nil
+	}
+
+	f.Seek(0, 0)
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return err
+	}
+
+	// Re-encoding drops all metadata too
+	var buf bytes.Buffer
+	resized := resizeImage(img, width)
+	if isJPEG(src) {
+		err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: imageQuality})
+	} else {
+		err = png.Encode(&buf, resized)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeFile(vpath, buf.Bytes())
+}
+
+// Is dst made after src was last changed
+func isFresh(src, dst string) bool {
+	fsrc, err := os.Stat(src)
+	if err != nil {
+		return false
+	}
+	fdst, err := os.Stat(dst)
+	if err != nil {
+		return false
+	}
+	return !fdst.ModTime().Before(fsrc.ModTime())
+}
+
+// Resize image to given width keeping aspect ratio
+// Every new pixel is average of source pixels it covers (box filter)
+// Good for downscaling, which is all we need
+func resizeImage(img image.Image, width int) *image.RGBA {
+	b := img.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	height := (srcH*width + srcW/2) / srcW
+	if height < 1 {
+		height = 1
+	}
+
+	// Premultiplied RGBA, so transparent pixels do not darken edges
+	src := image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcH/height, (y+1)*srcH/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcW/width, (x+1)*srcW/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[i])
+					g += uint32(src.Pix[i+1])
+					bl += uint32(src.Pix[i+2])
+					a += uint32(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// Remove EXIF from jpeg file (in place)
+// File is written only if there was something to remove
+func stripFileEXIF(fpath string) error {
+	buf, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+	stripped := stripEXIF(buf)
+	if len(stripped) == len(buf) {
+		return nil
+	}
+	return ioutil.WriteFile(fpath, stripped, 0644)
+}
+
+// Remove APP1 segments (EXIF, XMP) from jpeg data
+// Image data is not touched. Returns given buf if it's not jpeg
+func stripEXIF(buf []byte) []byte {
+	if len(buf) < 4 || buf[0] != 0xFF || buf[1] != 0xD8 {
+		return buf
+	}
+
+	out := make([]byte, 0, len(buf))
+	out = append(out, buf[:2]...) // SOI
+	i := 2
+	for i+4 <= len(buf) {
+		if buf[i] != 0xFF {
+			// Broken file, leave as is
+			return buf
+		}
+		marker := buf[i+1]
+
+		// Start of scan - rest is image data
+		if marker == 0xDA {
+			break
+		}
+
+		size := int(buf[i+2])<<8 | int(buf[i+3])
+		end := i + 2 + size
+		if end > len(buf) {
+			return buf
+		}
+		if marker != 0xE1 {
+			out = append(out, buf[i:end]...)
+		}
+		i = end
+	}
+
+	return append(out, buf[i:]...)
+}
+
+// srcset attribute value for image by path relative to FileURL
+// Only existing variants and original image are listed
+// Returns "" if image has no variants
+func (app *Application) imageSrcset(s *Snapshot, rpath string) string {
+	if len(app.imageWidths) == 0 || !isResizable(rpath) {
+		return ""
+	}
+
+	var items []string
+	for _, width := range app.imageWidths {
+		vpath := imageVariantPath(rpath, width)
+		if _, err := os.Stat(app.PublicPath + "/" + vpath); err == nil {
+			items = append(items, fmt.Sprintf("%s %dw", app.fileURL(s, vpath), width))
+		}
+	}
+	if len(items) == 0 {
+		return ""
+	}
+
+	// Original is the widest
+	if src := app.publicFile(s, rpath); src != "" {
+		if f, err := os.Open(src); err == nil {
+			if cfg, _, err := image.DecodeConfig(f); err == nil {
+				items = append(items, fmt.Sprintf("%s %dw", app.fileURL(s, rpath), cfg.Width))
+			}
+			f.Close()
+		}
+	}
+
+	return strings.Join(items, ", ")
+}
+
+// URL of image with given width by path relative to FileURL
+// Variant is made if it's not there yet (any width can be requested)
+// Returns original url if image can't be resized or is not wider
+func (app *Application) imageURL(s *Snapshot, rpath string, width int) string {
+	src := app.publicFile(s, rpath)
+	if width <= 0 || src == "" || !isResizable(rpath) {
+		return app.fileURL(s, rpath)
+	}
+
+	vpath := imageVariantPath(rpath, width)
+	if err := makeImageVariant(src, app.PublicPath+"/"+rpath, width); err != nil {
+		return app.fileURL(s, rpath)
+	}
+	if _, err := os.Stat(app.PublicPath + "/" + vpath); err != nil {
+		// Image is not wider than requested
+		return app.fileURL(s, rpath)
+	}
+	return app.fileURL(s, vpath)
+}
+
+// Parse widths from config
+// "320, 640,1280" --> [320 640 1280]
+func parseWidths(s string) []int {
+	var widths []int
+	for _, item := range strings.Split(s, ",") {
+		if width, err := strconv.Atoi(strings.TrimSpace(item)); err == nil && width > 0 {
+			widths = append(widths, width)
+		}
+	}
+	sort.Ints(widths)
+	return widths
+}
@@ -2,14 +2,144 @@ package mango
 
 import (
 	"bytes"
+	"io"
 
 	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
 )
 
-func parseMarkdown(buf []byte) []byte {
-	parser := parser.NewWithExtensions(parser.CommonExtensions | parser.AutoHeadingIDs)
+// MarkdownRenderer - converts markdown to HTML
+// Default one uses gomarkdown, other (goldmark..) can be set with app.SetMarkdown
+type MarkdownRenderer interface {
+	Render(src []byte, opts MarkdownOptions) []byte
+}
+
+// MarkdownRendererFunc - function as MarkdownRenderer
+type MarkdownRendererFunc func(src []byte, opts MarkdownOptions) []byte
+
+// Render - call f(src, opts)
+func (f MarkdownRendererFunc) Render(src []byte, opts MarkdownOptions) []byte {
+	return f(src, opts)
+}
+
+// MarkdownOptions - markdown extensions
+// Set in ".mango" and overridden by page params:
+//
+//	MarkdownFootnotes: Yes
+//	MarkdownHeadingIDPrefix: doc-
+type MarkdownOptions struct {
+	Footnotes       bool
+	Tables          bool
+	DefinitionLists bool
+	Math            bool
+	Smartypants     bool // smart quotes, dashes and fractions
+	HardLineBreaks  bool // every newline is <br>
+	HeadingIDPrefix string
+	Highlight       bool // fenced code with language is highlighted
+	LineNumbers     bool // line numbers in highlighted code
+}
+
+// Options used when not configured
+var defaultMarkdownOptions = MarkdownOptions{
+	Tables:          true,
+	DefinitionLists: true,
+	Math:            true,
+	Smartypants:     true,
+	Highlight:       true,
+}
+
+// Options from params (not set ones stays as in opts)
+func markdownOptions(opts MarkdownOptions, params map[string]string) MarkdownOptions {
+	flags := map[string]*bool{
+		"MarkdownFootnotes":       &opts.Footnotes,
+		"MarkdownTables":          &opts.Tables,
+		"MarkdownDefinitionLists": &opts.DefinitionLists,
+		"MarkdownMath":            &opts.Math,
+		"MarkdownSmartypants":     &opts.Smartypants,
+		"MarkdownHardLineBreaks":  &opts.HardLineBreaks,
+		"MarkdownHighlight":       &opts.Highlight,
+		"MarkdownLineNumbers":     &opts.LineNumbers,
+	}
+	for key, flag := range flags {
+		if b, err := parseBool(params[key]); err == nil && params[key] != "" {
+			*flag = b
+		}
+	}
+	if val, ok := params["MarkdownHeadingIDPrefix"]; ok {
+		opts.HeadingIDPrefix = val
+	}
+	return opts
+}
+
+// SetMarkdown - use other markdown renderer (nil - default)
+// Loaded content is loaded again with it (same way it was loaded)
+func (app *Application) SetMarkdown(r MarkdownRenderer) {
+	app.markdown = r
+	app.reloadContent()
+}
+
+// Markdown to HTML with app renderer and options
+// Page params override app options. Works with nil app too (defaults)
+func (app *Application) markdownToHTML(buf []byte, params map[string]string) []byte {
+	opts := defaultMarkdownOptions
+	var r MarkdownRenderer = gomarkdownRenderer{}
+	if app != nil {
+		opts = app.markdownOptions
+		if app.markdown != nil {
+			r = app.markdown
+		}
+	}
+	return r.Render(buf, markdownOptions(opts, params))
+}
+
+// Default renderer
+type gomarkdownRenderer struct{}
+
+func (gomarkdownRenderer) Render(buf []byte, opts MarkdownOptions) []byte {
+	extensions := parser.NoIntraEmphasis | parser.FencedCode | parser.Autolink |
+		parser.Strikethrough | parser.SpaceHeadings | parser.HeadingIDs |
+		parser.BackslashLineBreak | parser.AutoHeadingIDs
+	if opts.Tables {
+		extensions |= parser.Tables
+	}
+	if opts.DefinitionLists {
+		extensions |= parser.DefinitionLists
+	}
+	if opts.Math {
+		extensions |= parser.MathJax
+	}
+	if opts.Footnotes {
+		extensions |= parser.Footnotes
+	}
+	if opts.HardLineBreaks {
+		extensions |= parser.HardLineBreak
+	}
+
+	flags := html.CommonFlags
+	if !opts.Smartypants {
+		flags = html.FlagsNone
+	}
+	rOpts := html.RendererOptions{
+		Flags:           flags,
+		HeadingIDPrefix: opts.HeadingIDPrefix,
+	}
+	if opts.Highlight {
+		rOpts.RenderNodeHook = func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+			code, ok := node.(*ast.CodeBlock)
+			if !ok || !highlightCode(w, code.Literal, string(code.Info), opts) {
+				return ast.GoToNext, false
+			}
+			if !html.IsListItem(code.Parent) {
+				io.WriteString(w, "\n")
+			}
+			return ast.GoToNext, true
+		}
+	}
+	renderer := html.NewRenderer(rOpts)
+
 	buf = bytes.ReplaceAll(buf, []byte("\t"), []byte("    "))
 	buf = bytes.ReplaceAll(buf, []byte("\r"), nil)
-	return markdown.ToHTML(buf, parser, nil)
+	return markdown.ToHTML(buf, parser.NewWithExtensions(extensions), renderer)
 }
@@ -19,9 +19,19 @@ type Page struct {
 	// Link to application
 	App *Application
 
+	// Snapshot where page belongs to
+	// Other pages are looked up here
+	snap *Snapshot
+
 	// Content
 	content []byte
 
+	// Shortcodes in content (rendered on Content() call)
+	shortcodes []*Shortcode
+
+	// Headings in content (for TOC)
+	headings []Heading
+
 	// Params that describe this page
 	params map[string]string
 
@@ -53,25 +63,43 @@ func newPage(label string) *Page {
 }
 
 // fileToPage - create/init new page from existing file
-func fileToPage(fpath string) *Page {
+// Problems are reported to diag (can be nil)
+// Markdown is rendered with app renderer (app can be nil)
+func fileToPage(app *Application, fpath string, diag *Diagnostics) *Page {
 
 	// Extract content
-	params := FileToParams(fpath)
+	params := fileToParams(fpath, diag)
 	bufContent := []byte(params["Content"])
 	delete(params, "Content")
 
+	// {{< name arg="val" >}} --> placeholders
+	bufContent, shortcodes := extractShortcodes(bufContent)
+
 	// To markdown
 	if params["IsHTML"] == "Yes" {
 		// Do nothing for html pages
 		// leave as is
 	} else {
-		bufContent = parseMarkdown(bufContent)
+		bufContent = app.markdownToHTML(bufContent, params)
+	}
+
+	// Heading structure and permalinks: <h2 id="intro">
+	headings := extractHeadings(bufContent)
+
+	// WordCount, ReadingTime, Summary..
+	if params["HaveContent"] == _Yes {
+		setContentStats(params, bufContent)
+	}
+	if params["IsAnchors"] == _Yes {
+		bufContent = addHeadingAnchors(bufContent)
 	}
 
 	// Create new page
 	page := newPage("")
 	page.SetContent(bufContent)
 	page.params = params // assign original params
+	page.shortcodes = shortcodes
+	page.headings = headings
 
 	return page
 }
@@ -81,9 +109,9 @@ func fileToPage(fpath string) *Page {
 func (page *Page) SetLang(lang string) string {
 
 	// Check if language is valid in App scope
-	if page.App != nil {
-		if !page.App.IsValidLang(lang) && len(page.App.Pages) > 0 {
-			lang = page.App.Pages[0].Get("Slug")
+	if s := page.snapshot(); s != nil {
+		if !s.IsValidLang(lang) && len(s.Pages) > 0 {
+			lang = s.Pages[0].Get("Slug")
 		}
 	}
 
@@ -99,9 +127,9 @@ func (page *Page) SetContent(content []byte) {
 		// Ugly fix but go doesn't support negative lookup
 		// (?!:\\/|http?:ftp) to doesn't select strings that starts with these
 
-		// Get FileURl prefix
-		arr := strings.SplitN(page.App.URLTemplates["File"], "{File", 2)
-		prefix := arr[0]
+		// Fingerprinted urls are made again with fresh hash
+		// /images/logo.png?v=1a2b3c --> /images/logo.png?v=4d5e6f
+		isQueryHash := strings.Index(page.App.URLTemplates["File"], "?") >= 0 && page.App.isFileHash()
 
 		scopes := map[string]string{
 			"images": "src",
@@ -123,28 +151,55 @@ func (page *Page) SetContent(content []byte) {
 			ALTER -- data/file.pdf
 			ALTER -- /data/file.pdf
 			NO -- /file.pdf
+			NO -- #anchor
 			NO -- http://example.com/file.pdf
 		*/
+		// Assets that are not moved are linked by content-relative path
+		// (page.snap can be nil for virtual pages)
+		s := page.snapshot()
+
 		for scope, attr := range scopes {
-			re := regexp.MustCompile(` ` + attr + `="(.+?)"`)
+			// Followed by srcset if content was already processed
+			re := regexp.MustCompile(` ` + attr + `="(.+?)"( srcset=")?`)
 			all := re.FindAllSubmatch(content, -1)
 			for _, match := range all {
 				val := match[1]
-				// /images/logo.png --> logo.png
-				// images/logo.png --> logo.png
-				val = bytes.TrimPrefix(val, []byte("/"+scope+"/"))
-				val = bytes.TrimPrefix(val, []byte(scope+"/"))
-
-				if val[0] == '/' || bytes.Index(val, []byte(":")) >= 0 {
-					// starts with "/" or have schema (http://, ftp://)
-					// then skip
-					continue
+				isSrcset := attr == "src" && len(match[2]) == 0
+
+				// File path relative to FileURL
+				// logo.png --> assets/1_en/news/logo.png
+				rpath := ""
+				if s != nil {
+					rpath = s.assetFile(page, string(val))
 				}
-				val = bytes.TrimPrefix(val, []byte(scope+"/"))
+
+				if rpath == "" {
+					if isQueryHash {
+						val = bytes.SplitN(val, []byte("?"), 2)[0]
+					}
+
+					// /images/logo.png --> logo.png
+					// images/logo.png --> logo.png
+					val = bytes.TrimPrefix(val, []byte("/"+scope+"/"))
+					val = bytes.TrimPrefix(val, []byte(scope+"/"))
+
+					if val[0] == '/' || val[0] == '#' || bytes.Index(val, []byte(":")) >= 0 {
+						// starts with "/", in-page anchor "#intro" or have schema (http://, ftp://)
+						// then skip
+						continue
+					}
+					val = bytes.TrimPrefix(val, []byte(scope+"/"))
+					rpath = scope + "/" + string(val)
+				}
+
 				// construct valid url
-				val = []byte(prefix + scope + "/" + string(val))
+				url := page.App.fileURL(s, rpath)
 				old := []byte(fmt.Sprintf(attr+"=\"%s\"", match[1]))
-				new := []byte(fmt.Sprintf(attr+"=\"%s\"", val))
+				new := []byte(fmt.Sprintf(attr+"=\"%s\"", url))
+				if srcset := page.App.imageSrcset(s, rpath); isSrcset && srcset != "" {
+					// Responsive variants (ImageWidths)
+					new = append(new, fmt.Sprintf(" srcset=\"%s\"", srcset)...)
+				}
 				content = bytes.Replace(content, old, new, 1)
 				// fmt.Printf("src=\"%s\" ---> src=\"%s\"\n", match[1], src)
 				// fmt.Printf("href=\"%s\" ---> href=\"%s\"\n", match[1], href)
@@ -158,10 +213,25 @@ func (page *Page) SetContent(content []byte) {
 	page.Unlock()
 }
 
+// Shortcodes for placeholders in content
+func (page *Page) setShortcodes(shortcodes []*Shortcode) {
+	page.Lock()
+	page.shortcodes = shortcodes
+	page.Unlock()
+}
+
 // AppendContent - append to content
 func (page *Page) AppendContent(content []byte) {
-	pageContent := page.Content()
-	page.SetContent(append(pageContent, content...))
+	pageContent := page.rawContent()
+	page.SetContent(append(pageContent[:len(pageContent):len(pageContent)], content...))
+}
+
+// Content with shortcode placeholders (not rendered)
+func (page *Page) rawContent() []byte {
+	page.RLock()
+	defer page.RUnlock()
+
+	return page.content
 }
 
 // Content - get content for page
@@ -174,9 +244,13 @@ func (page *Page) Content() []byte {
 	}
 
 	page.RLock()
-	defer page.RUnlock()
+	content, shortcodes := page.content, page.shortcodes
+	page.RUnlock()
 
-	return page.content
+	if len(shortcodes) > 0 {
+		return page.renderShortcodes(content, shortcodes)
+	}
+	return content
 }
 
 // Params - return map safaly
@@ -265,6 +339,61 @@ func (page *Page) Split(key, sep string) []string {
 	return _arr
 }
 
+// GetInt - param as int (0 if not set or not a number)
+func (page *Page) GetInt(key string) int {
+	i, _ := strconv.Atoi(page.Get(key))
+	return i
+}
+
+// GetFloat - param as float64 (0 if not set or not a number)
+func (page *Page) GetFloat(key string) float64 {
+	f, _ := strconv.ParseFloat(page.Get(key), 64)
+	return f
+}
+
+// GetBool - param as bool
+// Yes, true, 1 are true; everything else false
+func (page *Page) GetBool(key string) bool {
+	b, _ := parseBool(page.Get(key))
+	return b
+}
+
+// GetTime - param as time (zero time if not set or invalid)
+// Any format ToTime understands, also unix (nano) time: ModTime, VisibleFrom
+func (page *Page) GetTime(key string) time.Time {
+	val := page.Get(key)
+	if val == "" {
+		return time.Time{}
+	}
+	dt, err := ToTime(val)
+	if err != nil {
+		return time.Time{}
+	}
+	return dt
+}
+
+// GetDuration - param as duration: 1h30m, 90s (0 if not set or invalid)
+func (page *Page) GetDuration(key string) time.Duration {
+	d, _ := time.ParseDuration(page.Get(key))
+	return d
+}
+
+// GetList - param as list of comma separated items
+// "dog, cat,, mouse" --> [dog cat mouse]
+func (page *Page) GetList(key string) []string {
+	return page.Split(key, ",")
+}
+
+// GetNested - nested param value (use with range in templates)
+// Gallery --> []interface{} of map[string]interface{}, Social --> map[string]interface{}
+// Plain param is returned as string, nil if not set
+func (page *Page) GetNested(key string) interface{} {
+	page.RLock()
+	defer page.RUnlock()
+
+	return nestedValue(page.params, key)
+}
+
 // IsEqual - shorthand to compare param with custom string
 func (page *Page) IsEqual(key, val string) bool {
 	return page.Get(key) == val
@@ -336,9 +465,28 @@ func (page *Page) ModTime() time.Time {
 	return time.Unix(i, 0) // time.Time from int64
 }
 
+// Snapshot - content where this page is loaded
+// Use it to look up other pages consistently
+func (page *Page) Snapshot() *Snapshot {
+	return page.snapshot()
+}
+
+// Snapshot page belongs to
+// Pages not loaded with content use current app snapshot
+func (page *Page) snapshot() *Snapshot {
+	page.RLock()
+	s := page.snap
+	page.RUnlock()
+
+	if s == nil && page.App != nil {
+		s = page.App.Snapshot()
+	}
+	return s
+}
+
 // Check if page is duplicate slug
 func (page *Page) isDuplicate() bool {
-	return page.App.Page(page.Get("Slug")) != nil
+	return page.snapshot().Page(page.Get("Slug")) != nil
 }
 
 // Is page is top level
@@ -453,7 +601,8 @@ func (page *Page) WalkTop(fn func(parent *Page)) {
 }
 
 // Search - find all pages by given search term
-// TODO: make correct search by params and content
+// Substring of Slug, Label, Title or html content
+// For ranked full-text search use app.Search
 func (page *Page) Search(sterm string) PageList {
 	sterm = strings.TrimSpace(sterm)
 	sterm = strings.ToLower(sterm)
@@ -535,11 +684,14 @@ func (page *Page) ReloadContent() bool {
 	page.Set("ModTime", fModTime) // set new modtime
 
 	// Read file
-	p2 := fileToPage(fpath)
+	p2 := fileToPage(page.App, fpath, nil)
 
 	// Set content
 	// Do not use p2.Content() as it will loop forever
 	page.SetContent(p2.content)
+	page.setShortcodes(p2.shortcodes)
+	page.setHeadings(p2.headings)
+	page.copyContentStats(p2)
 
 	return true
 }
@@ -630,7 +782,7 @@ func (page *Page) PrintRow() {
 
 	collectionStr := ""
 	if !p.IsSet("Redirect") {
-		for ckey := range p.App.collections {
+		for ckey := range p.snapshot().collections {
 			if p.IsSet(ckey) {
 				collectionStr += "[" + ckey[:1] + "]: "
 				cval := p.Get(ckey)
@@ -24,8 +24,8 @@ func (pages PageList) Swap(i, j int) {
 
 // Less is part of sort.Interface. We use count as the value to sort by
 func (pages PageList) Less(i, j int) bool {
-	iNum, _ := strconv.Atoi(pages[i].Get("SortNr"))
-	jNum, _ := strconv.Atoi(pages[j].Get("SortNr"))
+	iNum := pages[i].GetInt("SortNr")
+	jNum := pages[j].GetInt("SortNr")
 
 	if iNum == 0 || jNum == 0 {
 		// unset or broken SortNr. Do nothing
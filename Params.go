@@ -0,0 +1,310 @@
+package mango
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Nested params are kept flat with dotted keys
+//
+//	Gallery.0.Src: a.jpg
+//	Gallery.1.Src: b.jpg
+//	Social.Github: briiC
+//
+// Set in header as indented block or JSON value:
+//
+//	Social:
+//	  Github: briiC
+//	Gallery: [{"Src": "a.jpg"}, {"Src": "b.jpg"}]
+
+// JSON value as flat params
+// Returns false if value is not JSON list or object
+func jsonToParams(key, val string, set func(key, val string)) bool {
+	if val == "" || (val[0] != '[' && val[0] != '{') {
+		return false
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(val), &v); err != nil {
+		return false
+	}
+	flattenValue(key, v, set)
+	return true
+}
+
+// Nested value as flat params
+// List of plain values is joined: "dog, cat"
+func flattenValue(key string, v interface{}, set func(key, val string)) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, v2 := range v {
+			flattenValue(key+"."+k, v2, set)
+		}
+
+	case []interface{}:
+		vals := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				// Not plain list
+				for i, item := range v {
+					flattenValue(key+"."+strconv.Itoa(i), item, set)
+				}
+				return
+			}
+			if val := scalarToString(item); val != "" {
+				vals = append(vals, val)
+			}
+		}
+		set(key, strings.Join(vals, ", "))
+
+	default:
+		set(key, scalarToString(v))
+	}
+}
+
+func scalarToString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		if v {
+			return _Yes
+		}
+		return _No
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return valueToString(v)
+}
+
+// Nested value from flat params
+// Returns string for plain param, []interface{} for list (Gallery.0, Gallery.1),
+// map[string]interface{} for others (Social.Github) and nil if not set
+func nestedValue(params map[string]string, key string) interface{} {
+	if val, ok := params[key]; ok {
+		return val
+	}
+
+	// Direct children names
+	prefix := key + "."
+	names := make(map[string]bool, 0)
+	for k := range params {
+		if strings.HasPrefix(k, prefix) {
+			names[strings.SplitN(k[len(prefix):], ".", 2)[0]] = true
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	// List if all children are numbers
+	max := -1
+	for name := range names {
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 {
+			max = -1
+			break
+		}
+		if i > max {
+			max = i
+		}
+	}
+	if max >= 0 {
+		list := make([]interface{}, max+1)
+		for name := range names {
+			i, _ := strconv.Atoi(name)
+			list[i] = nestedValue(params, prefix+name)
+		}
+		return list
+	}
+
+	m := make(map[string]interface{}, len(names))
+	for name := range names {
+		m[name] = nestedValue(params, prefix+name)
+	}
+	return m
+}
+
+// Nested value by dotted path: Gallery.0.Src
+func pathValue(v interface{}, key string) interface{} {
+	for _, name := range strings.Split(key, ".") {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			v = vv[name]
+		case []interface{}:
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 || i >= len(vv) {
+				return nil
+			}
+			v = vv[i]
+		case map[string]string:
+			return nestedValue(vv, key)
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// Parents of nested keys (true if parent is list)
+// Gallery.0.Src --> Gallery: true, Gallery.0: false
+func nestedParents(params map[string]string) map[string]bool {
+	parents := make(map[string]bool, 0)
+	for key := range params {
+		parts := strings.Split(key, ".")
+		for i := 1; i < len(parts); i++ {
+			parent := strings.Join(parts[:i], ".")
+			if _, err := strconv.Atoi(parts[i]); err == nil {
+				parents[parent] = true
+			} else if !parents[parent] {
+				parents[parent] = false
+			}
+		}
+	}
+	return parents
+}
+
+// Is key already set in params as part of bigger value
+// Lists are not merged item by item: page Gallery replaces Gallery from .defaults
+// Plain value replaces nested and nested plain: "Social: none" over Social.Github
+func isNestedSet(params map[string]string, parents map[string]bool, key string) bool {
+	if _, ok := parents[key]; ok {
+		return true
+	}
+	for i := strings.Index(key, "."); i > 0; {
+		parent := key[:i]
+		if _, ok := params[parent]; ok || parents[parent] {
+			return true
+		}
+		next := strings.Index(key[i+1:], ".")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+// "-Key: val" row (not "-- comment")
+func isRemoveParam(row []byte) bool {
+	pos := strings.Index(string(row), ":")
+	return len(row) > 1 && unicode.IsLetter(rune(row[1])) &&
+		pos > 0 && strings.IndexAny(string(row[:pos]), " \t") < 0
+}
+
+// Is key (or its parent) cleared by !Key
+// Operator keys are checked by their base key: +Tags, -Tags --> Tags
+func isClearedParam(params map[string]string, key string) bool {
+	key = strings.TrimSuffix(strings.TrimLeft(key, "+-!"), "?")
+	for {
+		if _, ok := params["!"+key]; ok {
+			return true
+		}
+		pos := strings.LastIndex(key, ".")
+		if pos < 0 {
+			return false
+		}
+		key = key[:pos]
+	}
+}
+
+// Remove items from comma separated list
+// "dog, cat, mouse" - "cat" --> "dog, mouse"
+func removeItems(list, items string) string {
+	remove := make(map[string]bool, 0)
+	for _, item := range splitList(items) {
+		remove[strings.ToLower(item)] = true
+	}
+
+	var kept []string
+	for _, item := range splitList(list) {
+		if !remove[strings.ToLower(item)] {
+			kept = append(kept, item)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
+
+// Operators left after all merged
+//
+//	+Key: val -- nothing inherited to append to, so val
+//	Key?: val -- val if Key is still not set
+//	-Key, !Key -- nothing (more) to remove or clear
+func resolveParamOps(params map[string]string) {
+	parents := nestedParents(params)
+	for key, val := range params {
+		if key == "" {
+			continue
+		}
+		switch {
+		case key[0] == '+' || key[len(key)-1] == '?':
+			base := strings.TrimSuffix(strings.TrimPrefix(key, "+"), "?")
+			if _, isKey := params[base]; !isKey && !isNestedSet(params, parents, base) {
+				params[base] = val
+			}
+			delete(params, key)
+		case key[0] == '-' || key[0] == '!':
+			delete(params, key)
+		}
+	}
+}
+
+// Reference to other page param in value
+// {Parent.Title}, {Parent.Parent.Icon}, {about.Email} (by slug)
+var reParamRef = regexp.MustCompile(`\{([A-Za-z0-9_-]+)\.([A-Za-z0-9_.-]+)\}`)
+
+// Replace param references with values from other pages
+// Unknown pages are reported and references left as is
+func (app *Application) resolveParamRefs(s *Snapshot, p *Page) {
+	for key, val := range p.Params() {
+		if strings.Index(val, "{") < 0 {
+			continue
+		}
+		if val2 := s.paramRefs(p, key, val, 0); val2 != val {
+			p.Set(key, val2)
+		}
+	}
+}
+
+// Value with references replaced
+// References in referenced values are followed (not too deep)
+func (s *Snapshot) paramRefs(p *Page, key, val string, depth int) string {
+	return reParamRef.ReplaceAllStringFunc(val, func(ref string) string {
+		m := reParamRef.FindStringSubmatch(ref)
+		target, rkey := s.refPage(p, m[1], m[2])
+		if target == nil {
+			if depth == 0 {
+				s.diagnostics.addParam(p.Get("Path"), key, SeverityWarning, "param %q: page in reference %q not found", key, ref)
+			}
+			return ref
+		}
+
+		rval := target.Get(rkey)
+		if depth < 8 && strings.Index(rval, "{") >= 0 {
+			rval = s.paramRefs(target, rkey, rval, depth+1)
+		}
+		return rval
+	})
+}
+
+// Referenced page and its param key
+// Parent.Parent.Title --> grand parent, Title
+func (s *Snapshot) refPage(p *Page, name, key string) (*Page, string) {
+	if name != "Parent" {
+		target := s.Page(p.Get("Lang") + "-" + name)
+		if target == nil {
+			target = s.Page(name)
+		}
+		return target, key
+	}
+
+	target := p.Parent
+	for strings.HasPrefix(key, "Parent.") && target != nil {
+		target = target.Parent
+		key = strings.TrimPrefix(key, "Parent.")
+	}
+	return target, key
+}
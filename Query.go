@@ -0,0 +1,287 @@
+package mango
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Comparison operators in query (two-char first)
+var queryOps = []string{">=", "<=", "!=", ":", "=", ">", "<"}
+
+// Parsed page query
+// Tags:dog Categories:"House pets" -IsUnlisted:Yes ModTime>2024-01-01 sort:-ModTime
+type pageQuery struct {
+	filters []queryFilter
+	sorts   []querySort
+	in      string // slug of top page
+	limit   int
+}
+
+// Key:value -- value equal to param or one of its items (comma separated)
+// Key:*     -- param is set
+// Key=value -- exact value
+// Key>value -- greater (numbers, dates or text), also <, >=, <=, !=
+// -Key:value -- not
+type queryFilter struct {
+	key   string
+	op    string
+	value string
+	not   bool
+}
+
+// sort:Key, sort:-Key (descending)
+type querySort struct {
+	key  string
+	desc bool
+}
+
+// Query - find pages by query
+// Tags:dog Categories:"House pets" -IsUnlisted:Yes ModTime>2024-01-01 sort:-ModTime
+// Returns nil if query is not valid
+func (app *Application) Query(query string) PageList {
+	return app.Snapshot().Query(query)
+}
+
+// Query - find pages by query
+// Returns nil if query is not valid
+func (s *Snapshot) Query(query string) PageList {
+	q, err := parsePageQuery(query)
+	if err != nil {
+		return nil
+	}
+	return s.runQuery(q, nil)
+}
+
+// Query - find sub-pages (at any depth) by query
+// Returns nil if query is not valid
+func (page *Page) Query(query string) PageList {
+	q, err := parsePageQuery(query)
+	if err != nil {
+		return nil
+	}
+	return page.snapshot().runQuery(q, page)
+}
+
+// Parse query string
+func parsePageQuery(query string) (*pageQuery, error) {
+	q := &pageQuery{}
+
+	for _, token := range splitQuery(query) {
+		f := queryFilter{}
+		if token[0] == '-' {
+			f.not = true
+			token = token[1:]
+		}
+
+		// First operator in token
+		pos := -1
+		for _, op := range queryOps {
+			if i := strings.Index(token, op); i > 0 && (pos < 0 || i < pos) {
+				pos = i
+				f.op = op
+			}
+		}
+		if pos < 0 {
+			return nil, fmt.Errorf("query: %q has no operator (Key:value)", token)
+		}
+		f.key = token[:pos]
+		f.value = strings.Trim(token[pos+len(f.op):], `"`)
+
+		switch {
+		case f.key == "sort" && f.op == ":":
+			q.sorts = append(q.sorts, querySort{strings.TrimPrefix(f.value, "-"), strings.HasPrefix(f.value, "-")})
+		case f.key == "in" && f.op == ":":
+			q.in = f.value
+		case f.key == "limit" && f.op == ":":
+			limit, err := strconv.Atoi(f.value)
+			if err != nil || limit < 1 {
+				return nil, fmt.Errorf("query: incorrect limit %q", f.value)
+			}
+			q.limit = limit
+		case f.value == "" && f.op != "=" && f.op != "!=":
+			return nil, fmt.Errorf("query: %q has no value", token)
+		default:
+			q.filters = append(q.filters, f)
+		}
+	}
+
+	if len(q.filters) == 0 && q.in == "" {
+		return nil, errors.New("query: no filters")
+	}
+	return q, nil
+}
+
+// Split query by spaces (not in quotes)
+func splitQuery(query string) []string {
+	var tokens []string
+	var token strings.Builder
+	isQuoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			isQuoted = !isQuoted
+			token.WriteRune(r)
+		case r == ' ' && !isQuoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+// Find pages in snapshot (or under top page) by query
+// Pages are ordered by path if no sort given
+func (s *Snapshot) runQuery(q *pageQuery, top *Page) PageList {
+	if q.in != "" {
+		if top = s.Page(q.in); top == nil {
+			return nil
+		}
+	}
+
+	var pages PageList
+	if top != nil {
+		pages = top.Walk(q.isMatch)
+	} else {
+		pages = s.slugPages.Filter(func(p *Page) bool {
+			// Not .defaults pages
+			return !strings.HasPrefix(filepath.Base(p.Get("Path")), ".") && q.isMatch(p)
+		})
+		sort.SliceStable(pages, func(i, j int) bool {
+			return pages[i].Get("Path") < pages[j].Get("Path")
+		})
+	}
+
+	if len(q.sorts) > 0 {
+		sort.SliceStable(pages, func(i, j int) bool {
+			for _, qs := range q.sorts {
+				c := compareValues(queryParam(pages[i], qs.key), queryParam(pages[j], qs.key))
+				if c != 0 {
+					return (c < 0) != qs.desc
+				}
+			}
+			return false
+		})
+	}
+
+	if q.limit > 0 && len(pages) > q.limit {
+		pages = pages[:q.limit]
+	}
+	return pages
+}
+
+// Is page matching all filters
+func (q *pageQuery) isMatch(p *Page) bool {
+	for _, f := range q.filters {
+		if f.isMatch(p) == f.not {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *queryFilter) isMatch(p *Page) bool {
+	val := queryParam(p, f.key)
+
+	switch f.op {
+	case ":":
+		if f.value == "*" {
+			return val != ""
+		}
+		if strings.EqualFold(val, f.value) {
+			return true
+		}
+		for _, item := range strings.Split(val, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), f.value) {
+				return true
+			}
+		}
+		return false
+	case "=":
+		return val == f.value
+	case "!=":
+		return val != f.value
+	}
+
+	// Not set params are not compared
+	if val == "" {
+		return false
+	}
+	c := compareValues(val, f.value)
+	switch f.op {
+	case ">":
+		return c > 0
+	case "<":
+		return c < 0
+	case ">=":
+		return c >= 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
+
+// Param value for query
+// Collection keys in any form: Tag, Tags
+func queryParam(p *Page, key string) string {
+	if val := p.Get(key); val != "" {
+		return val
+	}
+	if s := p.snapshot(); s != nil {
+		if c := s.Collection(key); c != nil {
+			for ckey, c2 := range s.collections {
+				if c2 == c && p.IsSet(ckey) {
+					return p.Get(ckey)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Compare two param values
+// Dates (and unix times) as dates, numbers as numbers, others as text
+func compareValues(a, b string) int {
+	if isDateValue(a) || isDateValue(b) {
+		ta, errA := ToTime(a)
+		tb, errB := ToTime(b)
+		if errA == nil && errB == nil {
+			switch {
+			case ta.Before(tb):
+				return -1
+			case ta.After(tb):
+				return 1
+			}
+			return 0
+		}
+	}
+
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// Looks like date: 2024-01-02, 02.01.2024, 01/02/2024 (with time)
+func isDateValue(s string) bool {
+	return len(s) >= 8 && strings.ContainsAny(s, "-./") && strings.IndexAny(s, "0123456789") == 0
+}
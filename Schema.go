@@ -0,0 +1,247 @@
+package mango
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema file name
+// Applies to pages in same directory and all sub-directories
+// (nearest one is used)
+const schemaFname = ".schema"
+
+// Param types in schema
+const (
+	typeString   = "string"
+	typeInt      = "int"
+	typeFloat    = "float"
+	typeBool     = "bool"
+	typeTime     = "time"
+	typeDuration = "duration"
+	typeList     = "list"
+	typeURL      = "url"
+)
+
+// Rules for one param
+// Line in .schema file: "Key: type, required, value1|value2"
+//
+//	Date: time, required
+//	Weight: int
+//	Status: string, required, draft|published
+//	Tags: list, dog|cat|mouse
+type paramRule struct {
+	typ        string
+	isRequired bool
+	values     []string // allowed values (for lists - items)
+}
+
+// Parse .schema file
+// Problems in schema itself are reported to diag
+func fileToSchema(fpath string, diag *Diagnostics) map[string]paramRule {
+	buf, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			diag.Add(fpath, 0, SeverityError, "can't read file: %v", err)
+		}
+		return nil
+	}
+
+	schema := make(map[string]paramRule, 0)
+	params := bufToParams(buf, true, fpath, diag)
+	for key, val := range params {
+		if !strings.Contains(", "+params["SourceParams"], ", "+key+", ") {
+			// Added by bufToParams
+			continue
+		}
+
+		rule := paramRule{typ: typeString}
+		for i, item := range strings.Split(val, ",") {
+			item = strings.TrimSpace(item)
+			switch {
+			case i == 0 && isParamType(item):
+				rule.typ = item
+			case item == "required":
+				rule.isRequired = true
+			case strings.Index(item, "|") >= 0:
+				for _, v := range strings.Split(item, "|") {
+					rule.values = append(rule.values, strings.TrimSpace(v))
+				}
+			case item != "":
+				diag.addParam(fpath, key, SeverityWarning, "unknown schema rule %q for %q", item, key)
+			}
+		}
+		schema[key] = rule
+	}
+	return schema
+}
+
+// Parsed .schema files of one content load
+// Every file is read once, lookups do not go above root (ContentPath)
+type schemaCache struct {
+	sync.Mutex
+	root  string
+	dirs  map[string]string               // dir --> nearest .schema ("" - none)
+	rules map[string]map[string]paramRule // .schema --> rules
+}
+
+func newSchemaCache(root string) *schemaCache {
+	return &schemaCache{
+		root:  root,
+		dirs:  make(map[string]string, 0),
+		rules: make(map[string]map[string]paramRule, 0),
+	}
+}
+
+// Nearest .schema file for page in given directory
+// Returns "" if there is none (or directory is not in root)
+func (sc *schemaCache) find(dir string) string {
+	if fpath, ok := sc.dirs[dir]; ok {
+		return fpath
+	}
+	if dir != sc.root && !strings.HasPrefix(dir, sc.root+"/") {
+		return ""
+	}
+
+	fpath := dir + "/" + schemaFname
+	if _, err := os.Stat(fpath); err != nil {
+		fpath = ""
+		if dir != sc.root {
+			fpath = sc.find(filepath.Dir(dir))
+		}
+	}
+	sc.dirs[dir] = fpath
+	return fpath
+}
+
+// Rules of nearest schema for page in given directory
+// Returns schema file path and its rules ("", nil if none)
+func (sc *schemaCache) schema(dir string, diag *Diagnostics) (string, map[string]paramRule) {
+	if sc == nil {
+		return "", nil
+	}
+	sc.Lock()
+	defer sc.Unlock()
+
+	fpath := sc.find(dir)
+	if fpath == "" {
+		return "", nil
+	}
+	rules, ok := sc.rules[fpath]
+	if !ok {
+		rules = fileToSchema(fpath, diag)
+		sc.rules[fpath] = rules
+	}
+	return fpath, rules
+}
+
+// Check page params by nearest schema
+// Problems are reported on page file
+func validateParams(params map[string]string, dir string, diag *Diagnostics) {
+	if diag == nil {
+		// Nobody to tell
+		return
+	}
+	fpath, rules := diag.schemas.schema(dir, diag)
+	if fpath == "" {
+		return
+	}
+
+	path := params["Path"]
+	for key, rule := range rules {
+		val := params[key]
+		if val == "" {
+			if rule.isRequired {
+				diag.Add(path, 0, SeverityError, "required param %q not set (%s)", key, fpath)
+			}
+			continue
+		}
+
+		if err := checkParamType(rule.typ, val); err != nil {
+			diag.addParam(path, key, SeverityError, "param %q: %v", key, err)
+			continue
+		}
+
+		if len(rule.values) > 0 {
+			items := []string{val}
+			if rule.typ == typeList {
+				items = splitList(val)
+			}
+			for _, item := range items {
+				if !inList(rule.values, item) {
+					diag.addParam(path, key, SeverityError, "param %q: %q is not one of %s", key, item, strings.Join(rule.values, ", "))
+				}
+			}
+		}
+	}
+}
+
+// Is known param type
+func isParamType(typ string) bool {
+	switch typ {
+	case typeString, typeInt, typeFloat, typeBool, typeTime, typeDuration, typeList, typeURL:
+		return true
+	}
+	return false
+}
+
+// Check value by type
+func checkParamType(typ, val string) error {
+	var err error
+	switch typ {
+	case typeInt:
+		_, err = strconv.Atoi(val)
+	case typeFloat:
+		_, err = strconv.ParseFloat(val, 64)
+	case typeBool:
+		_, err = parseBool(val)
+	case typeTime:
+		_, err = ToTime(val)
+	case typeDuration:
+		_, err = time.ParseDuration(val)
+	case typeURL:
+		_, err = url.Parse(val)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not %s", val, typ)
+	}
+	return nil
+}
+
+// Parse mango style bool
+func parseBool(val string) (bool, error) {
+	switch strings.ToLower(val) {
+	case "yes", "true", "1":
+		return true, nil
+	case "no", "false", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not Yes or No", val)
+}
+
+// Items of comma separated list
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Is string in list
+func inList(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
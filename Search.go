@@ -0,0 +1,457 @@
+package mango
+
+import (
+	"html"
+	"html/template"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Searchable page fields (index in searchDoc.fields)
+const (
+	fieldTitle = iota
+	fieldLabel
+	fieldTags
+	fieldBody
+	fieldCount
+)
+
+// Weight of term found in field
+var fieldWeights = [fieldCount]float64{
+	fieldTitle: 8,
+	fieldLabel: 4,
+	fieldTags:  2,
+	fieldBody:  1,
+}
+
+// Snippet length (bytes) around first match in body
+const snippetLen = 200
+
+// SearchResult - found page with its score
+// and part of content with highlighted (<mark>) matches
+type SearchResult struct {
+	Page    *Page
+	Score   float64
+	Snippet template.HTML
+}
+
+// Inverted index of page terms
+// Built on load, updated with page reloads
+type searchIndex struct {
+	sync.RWMutex
+
+	docs map[*Page]*searchDoc
+
+	// Pages and weighted count of term in them
+	// terms["dog"][page] = 10.0
+	terms map[string]map[*Page]float64
+}
+
+// Indexed page
+type searchDoc struct {
+	fields [fieldCount][]string // stems in order (for phrases)
+	text   string               // plain text of content
+	body   []searchToken        // stems of text with positions
+}
+
+// One word
+type searchToken struct {
+	stem       string
+	start, end int // bytes in text
+}
+
+// Parsed search term
+// dog "black cat" --> terms: [dog black cat], phrases: [[black cat]]
+type searchQuery struct {
+	terms   []string
+	phrases [][]string
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		docs:  make(map[*Page]*searchDoc, 0),
+		terms: make(map[string]map[*Page]float64, 0),
+	}
+}
+
+// Copy of index to be changed (partial reload)
+// Pages are replaced with their copies in new snapshot
+func (idx *searchIndex) clone(copies map[*Page]*Page) *searchIndex {
+	idx.RLock()
+	defer idx.RUnlock()
+
+	idx2 := newSearchIndex()
+	for p, doc := range idx.docs {
+		idx2.docs[copies[p]] = doc
+	}
+	for term, pages := range idx.terms {
+		pages2 := make(map[*Page]float64, len(pages))
+		for p, w := range pages {
+			pages2[copies[p]] = w
+		}
+		idx2.terms[term] = pages2
+	}
+	return idx2
+}
+
+// Add page to index (replaces previous one)
+// Page content must be final (after ContentFrom)
+func (idx *searchIndex) add(page *Page, ckeys []string) {
+	lang := page.Get("Lang")
+	doc := &searchDoc{}
+
+	doc.fields[fieldTitle] = stems(tokenize(page.Get("Title")), lang)
+	doc.fields[fieldLabel] = stems(tokenize(page.Get("Label")), lang)
+	for _, ckey := range ckeys {
+		doc.fields[fieldTags] = append(doc.fields[fieldTags], stems(tokenize(page.Get(ckey)), lang)...)
+	}
+
+	doc.text = htmlToText(string(page.Content()))
+	for _, tok := range tokenize(doc.text) {
+		if stem := stemWord(tok.stem, lang); stem != "" {
+			tok.stem = stem
+			doc.body = append(doc.body, tok)
+		}
+	}
+	doc.fields[fieldBody] = stems(doc.body, "")
+
+	idx.Lock()
+	defer idx.Unlock()
+
+	idx.remove(page)
+	idx.docs[page] = doc
+	for field, terms := range doc.fields {
+		for _, term := range terms {
+			idx.termPages(term)[page] += fieldWeights[field]
+		}
+	}
+}
+
+// Remove page from index
+// Must be called with lock
+func (idx *searchIndex) remove(page *Page) {
+	doc, ok := idx.docs[page]
+	if !ok {
+		return
+	}
+	delete(idx.docs, page)
+
+	for _, terms := range doc.fields {
+		for _, term := range terms {
+			if _, ok := idx.terms[term][page]; !ok {
+				continue
+			}
+			pages := idx.termPages(term)
+			delete(pages, page)
+			if len(pages) == 0 {
+				delete(idx.terms, term)
+			}
+		}
+	}
+}
+
+// Remove page from index (thread-safe)
+func (idx *searchIndex) removePage(page *Page) {
+	idx.Lock()
+	idx.remove(page)
+	idx.Unlock()
+}
+
+// Pages of term (created if missing)
+// Must be called with lock
+func (idx *searchIndex) termPages(term string) map[*Page]float64 {
+	pages := idx.terms[term]
+	if pages == nil {
+		pages = make(map[*Page]float64, 1)
+		idx.terms[term] = pages
+	}
+	return pages
+}
+
+// Find pages with all terms and phrases
+// Best matches first
+func (idx *searchIndex) search(sterm, lang string, fnCheck func(p *Page) bool) []SearchResult {
+	q := parseQuery(sterm, lang)
+	if len(q.terms) == 0 {
+		return nil
+	}
+
+	idx.RLock()
+	defer idx.RUnlock()
+
+	// Rarest terms are worth more
+	n := float64(len(idx.docs))
+	scores := make(map[*Page]float64, 0)
+	for i, term := range q.terms {
+		pages := idx.terms[term]
+		idf := math.Log(1 + n/float64(len(pages)+1))
+
+		for p, w := range pages {
+			if _, ok := scores[p]; !ok && i > 0 {
+				// Not found by previous terms
+				continue
+			}
+			scores[p] += w * idf
+		}
+
+		// Only pages with all terms
+		for p := range scores {
+			if _, ok := pages[p]; !ok {
+				delete(scores, p)
+			}
+		}
+	}
+
+	var results []SearchResult
+	for p, score := range scores {
+		doc := idx.docs[p]
+		if !fnCheck(p) {
+			continue
+		}
+
+		// Phrases must be found in any field
+		isFound := true
+		for _, phrase := range q.phrases {
+			found := false
+			for field, terms := range doc.fields {
+				if hasPhrase(terms, phrase) {
+					score += fieldWeights[field] * float64(len(phrase))
+					found = true
+				}
+			}
+			isFound = isFound && found
+		}
+		if !isFound {
+			continue
+		}
+
+		results = append(results, SearchResult{
+			Page:    p,
+			Score:   score,
+			Snippet: doc.snippet(q.terms),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Page.Get("Path") < results[j].Page.Get("Path")
+	})
+
+	return results
+}
+
+// Part of text around first match
+// Matched words are wrapped in <mark>
+func (doc *searchDoc) snippet(terms []string) template.HTML {
+	isTerm := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		isTerm[term] = true
+	}
+
+	// Start a bit before first match (from word start)
+	start := 0
+	for _, tok := range doc.body {
+		if isTerm[tok.stem] && tok.start > snippetLen/4 {
+			start = tok.start
+			if i := strings.IndexByte(doc.text[tok.start-snippetLen/4:tok.start], ' '); i >= 0 {
+				start = tok.start - snippetLen/4 + i + 1
+			}
+			break
+		}
+		if isTerm[tok.stem] {
+			break
+		}
+	}
+
+	// End on word end
+	end := start + snippetLen
+	if end >= len(doc.text) {
+		end = len(doc.text)
+	} else if i := strings.LastIndexByte(doc.text[start:end], ' '); i > 0 {
+		end = start + i
+	} else {
+		for end > start && !utf8.RuneStart(doc.text[end]) {
+			end--
+		}
+	}
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("… ")
+	}
+	pos := start
+	for _, tok := range doc.body {
+		if tok.start < start || tok.end > end || !isTerm[tok.stem] {
+			continue
+		}
+		sb.WriteString(html.EscapeString(doc.text[pos:tok.start]))
+		sb.WriteString("<mark>" + html.EscapeString(doc.text[tok.start:tok.end]) + "</mark>")
+		pos = tok.end
+	}
+	sb.WriteString(html.EscapeString(doc.text[pos:end]))
+	if end < len(doc.text) {
+		sb.WriteString(" …")
+	}
+
+	return template.HTML(sb.String())
+}
+
+// Is phrase in terms as consecutive words
+func hasPhrase(terms, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(terms); i++ {
+		found := true
+		for j := range phrase {
+			if terms[i+j] != phrase[j] {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse search term to stemmed terms and phrases
+// Words in double quotes are phrase
+func parseQuery(sterm, lang string) searchQuery {
+	var q searchQuery
+	seen := make(map[string]bool, 0)
+
+	for i, part := range strings.Split(sterm, `"`) {
+		terms := stems(tokenize(part), lang)
+		for _, term := range terms {
+			if !seen[term] {
+				seen[term] = true
+				q.terms = append(q.terms, term)
+			}
+		}
+
+		// Odd parts are in quotes
+		if i%2 == 1 && len(terms) > 1 {
+			q.phrases = append(q.phrases, terms)
+		}
+	}
+
+	return q
+}
+
+// Split text to lowercase words
+// Token stem is the word itself (not stemmed yet)
+func tokenize(text string) []searchToken {
+	var tokens []searchToken
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			tokens = append(tokens, searchToken{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, searchToken{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+// Stems of tokens without stop words
+// Empty lang - tokens are already stemmed
+func stems(tokens []searchToken, lang string) []string {
+	var terms []string
+	for _, tok := range tokens {
+		stem := tok.stem
+		if lang != "" {
+			stem = stemWord(stem, lang)
+		}
+		if stem != "" {
+			terms = append(terms, stem)
+		}
+	}
+	return terms
+}
+
+// Stop words by language
+var stopWords = map[string]map[string]bool{
+	"en": wordSet("a an and are as at be but by for from has have he her his i if in into is it its me my no not of on or our she so than that the their them then there these they this to was we were what when where which who will with you your"),
+	"lv": wordSet("ar arī bet bija būs es gan ir jau jūs ka kā kad kas kur lai līdz mēs nav no par pēc pie šī šis tā tas tie tikai to tu un uz vai vēl viņa viņi viņš"),
+	"ru": wordSet("а без был была были было быть в вам вас во вот все всё вы где да для до его ее её если есть еще ещё же за и из или их к как когда кто ли мы на над не нет ни но о об однако он она они оно от по под при с со так также то только ты у уже что чтобы это этот я"),
+}
+
+// Word endings removed by stemming (longest first)
+var stemSuffixes = map[string][]string{
+	"en": sortSuffixes("fulness ousness iveness ingly edly ness ings ing ed ly"),
+	"lv": sortSuffixes("ajiem ajām ajos ajās ošs oša ošo ošā iem ēm īm os ās ēs īs um us ai am ei im ot ām ā ē ī a e i o u s š"),
+	"ru": sortSuffixes("иями ями ами ого его ому ему ыми ими ией ая яя ое ее ые ие ый ий ой ей ом ем ам ям ах ях ов ев ую юю ию ть ться ет ют ит ат ят ал ил ыл ла ли ло ы и а я о е ь у ю й"),
+}
+
+// Stem of lowercase word by language
+// Returns "" for stop words
+// Light stemming: only common endings are removed
+// and stem is never shorter than 3 letters
+func stemWord(word, lang string) string {
+	if stopWords[lang][word] {
+		return ""
+	}
+
+	// Plural first: cats, boxes, cities
+	if lang == "en" {
+		word = stemPlural(word)
+	}
+
+	for _, suffix := range stemSuffixes[lang] {
+		if strings.HasSuffix(word, suffix) && utf8.RuneCountInString(word)-utf8.RuneCountInString(suffix) >= 3 {
+			word = strings.TrimSuffix(word, suffix)
+			break
+		}
+	}
+
+	// bake, baked, baking --> bak
+	if lang == "en" && len(word) > 3 && strings.HasSuffix(word, "e") {
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+// Singular of english word
+func stemPlural(word string) string {
+	switch {
+	case len(word) < 4 || !strings.HasSuffix(word, "s"):
+		return word
+	case strings.HasSuffix(word, "ies") && len(word) > 5:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "xes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	}
+	return word[:len(word)-1]
+}
+
+// Set of space separated words
+func wordSet(words string) map[string]bool {
+	m := make(map[string]bool, 0)
+	for _, word := range strings.Fields(words) {
+		m[word] = true
+	}
+	return m
+}
+
+// Space separated suffixes from longest to shortest
+func sortSuffixes(suffixes string) []string {
+	arr := strings.Fields(suffixes)
+	sort.SliceStable(arr, func(i, j int) bool {
+		return utf8.RuneCountInString(arr[i]) > utf8.RuneCountInString(arr[j])
+	})
+	return arr
+}
@@ -1,12 +1,19 @@
 package mango
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -30,17 +37,33 @@ type Server struct {
 	}
 	*/
 	Middlewares map[string]func(next http.Handler) http.Handler
+
+	// Max size (bytes) of rendered pages kept in memory
+	// 0 - pages are rendered on every request (default, see "CacheSize" in config)
+	CacheSize int64
+
+	// Rendered pages (dropped on content and template changes)
+	cache *renderCache
+
+	// Guards Templates (replaced on reload while pages are rendered)
+	templatesMu sync.RWMutex
 }
 
 // NewServer - create server instance
 func NewServer(port int) *Server {
-
 	app, _ := NewApplication()
+	return NewAppServer(app, port)
+}
 
+// NewAppServer - create server instance for already created application
+func NewAppServer(app *Application, port int) *Server {
 	srv := &Server{
 		Host: "localhost",
 		Port: fmt.Sprintf("%d", port),
 		App:  app,
+
+		CacheSize: app.cacheSize,
+		cache:     newRenderCache(),
 	}
 
 	srv.Router = mux.NewRouter()
@@ -64,6 +87,15 @@ func (srv *Server) preStart() http.Handler {
 	r.HandleFunc("/", srv.RunIndex)
 	r.HandleFunc("/{Lang:[a-z]{2}}/", srv.RunIndex)
 
+	// Search (before pages, so it's not taken as slug)
+	if route := srv.App.URLTemplates["Search"]; route != "" {
+		r.HandleFunc(route, srv.RunSearch)
+	}
+
+	// Feeds: /feeds/en/rss.xml
+	// Other files under /feeds/ are served as public files
+	r.HandleFunc("/"+feedsDir+"/{Feed:.+}", srv.RunFeed).MatcherFunc(srv.isFeedRequest)
+
 	// Pages (by slug)
 	if route := srv.App.URLTemplates["Page"]; route != "" {
 		r.HandleFunc(route, srv.RunOne)
@@ -71,31 +103,28 @@ func (srv *Server) preStart() http.Handler {
 
 	// Files (by file path)
 	if route := srv.App.URLTemplates["File"]; route != "" {
-		// get prefix to strip
-		arr := strings.SplitN(route, "{File", 2)
-		prefix := arr[0]
-
-		fs := http.FileServer(http.Dir(srv.App.PublicPath))
+		fs := srv.fileServer()
 
 		// Middlewares (for files)
 		if mw, haveMw := srv.Middlewares["File"]; haveMw {
 			fs = mw(fs)
 		}
 
-		fs = http.StripPrefix(prefix, fs)
-		r.Handle(route, fs)
+		// Query is not part of route: /{File}?v={Hash}
+		route = strings.SplitN(route, "?", 2)[0]
+		r.Handle(route, srv.fileRoute(fs))
 	}
 
 	// Serve "naked" files. No prefixes, no versions
 	// This does nothing if FileURL is: /{File}
 	// but mandatory if FileURL is more complex: /static/{File}
-	// These lines makes sure we can serve root files: /sitemap.xml
-	fs := http.FileServer(http.Dir(srv.App.PublicPath))
+	// These lines makes sure we can serve root files: /sitemap.xml, /sitemap-en.xml.gz
+	fs := srv.fileServer()
 	// Middlewares for these files too
 	if mw, haveMw := srv.Middlewares["File"]; haveMw {
 		fs = mw(fs)
 	}
-	r.Handle("/{file:.+\\.[a-z]{3,4}}", fs)
+	r.Handle("/{file:.+\\.[a-z]{2,4}}", fs)
 
 	// 404
 	r.NotFoundHandler = http.HandlerFunc(srv.Run404)
@@ -107,18 +136,53 @@ func (srv *Server) preStart() http.Handler {
 		rh = mw(rh)
 	}
 
+	srv.setTemplates(template.Must(srv.loadTemplates()))
+	srv.cache.purge()
+
+	return rh
+}
+
+// Parsed templates (loaded on first use)
+// Safe to call while templates are reloaded
+func (srv *Server) templates() (*template.Template, error) {
+	srv.templatesMu.RLock()
+	tmpl := srv.Templates
+	srv.templatesMu.RUnlock()
+	if tmpl != nil {
+		return tmpl, nil
+	}
+
+	srv.templatesMu.Lock()
+	defer srv.templatesMu.Unlock()
+	if srv.Templates == nil {
+		tmpl, err := srv.loadTemplates()
+		if err != nil {
+			return nil, err
+		}
+		srv.Templates = tmpl
+	}
+	return srv.Templates, nil
+}
+
+// Replace templates for next renders
+func (srv *Server) setTemplates(tmpl *template.Template) {
+	srv.templatesMu.Lock()
+	srv.Templates = tmpl
+	srv.templatesMu.Unlock()
+}
+
+// Parse templates from bin path
+func (srv *Server) loadTemplates() (*template.Template, error) {
 	// Try minified templates first
 	// If not found use originals
-	templatePath := srv.App.BinPath() + "/templates/min"
+	templatePath := srv.App.templatePath() + "/min"
 	if _, err := ioutil.ReadFile(templatePath + "/layout.tmpl"); err != nil {
-		templatePath = srv.App.BinPath() + "/templates"
+		templatePath = srv.App.templatePath()
 	}
-	srv.Templates = template.Must(template.New("#mango#").
+	return template.New("#mango#").
 		Funcs(defaultFuncMap). // fill with defaults
 		Funcs(srv.FuncMap).    // user adds/overwrites his own
-		ParseGlob(templatePath + "/*.tmpl"))
-
-	return rh
+		ParseGlob(templatePath + "/*.tmpl")
 }
 
 // Start listening to port (default)
@@ -145,7 +209,7 @@ func (srv *Server) RunIndex(w http.ResponseWriter, r *http.Request) {
 
 	// Default params taken from /content/{lang}/.defaults
 	page := srv.App.NewPage(lang, "")
-	srv.Render(w, page, "index")
+	srv.servePage(w, r, page, "index")
 }
 
 // RunOne - handler for specific one (*Page)
@@ -153,7 +217,9 @@ func (srv *Server) RunOne(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug := vars["Slug"]
 
-	page := srv.App.Page(slug)
+	// Same content for whole request
+	// even if reload happens meanwhile
+	page := srv.App.Snapshot().Page(slug)
 
 	if page == nil {
 		srv.Run404(w, r)
@@ -171,13 +237,157 @@ func (srv *Server) RunOne(w http.ResponseWriter, r *http.Request) {
 	// Redirect detected by param
 	if redirectURL := page.Get("Redirect"); redirectURL != "" {
 		http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
+		return
 	}
 
 	templateID := "one"
 	if page.IsDir() {
 		templateID = "group"
 	}
-	srv.Render(w, page, templateID)
+	srv.servePage(w, r, page, templateID)
+}
+
+// RunSearch - handler for search page
+// ?q=search+term&p=2
+// Renders "search" template with found pages in page.Pages (one page of them)
+// or responds with JSON if client accepts it (autocomplete)
+func (srv *Server) RunSearch(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sterm := r.URL.Query().Get("q")
+	pNum, _ := strconv.Atoi(r.URL.Query().Get("p"))
+
+	// Default params taken from /content/{lang}/.defaults
+	page := srv.App.NewPage(vars["Lang"], "")
+	page.Set("SearchTerm", sterm)
+
+	// Same content for whole request
+	results := page.snapshot().SearchResults(page.Get("Lang"), sterm)
+	for _, res := range results {
+		page.Pages = append(page.Pages, res.Page)
+	}
+
+	pSize := page.GetInt("SearchSize")
+	if pSize < 1 {
+		pSize = searchPageSize
+	}
+	page.Paging(pNum, pSize, 0)
+
+	// Same url for html and json
+	w.Header().Set("Vary", "Accept")
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		from := page.GetInt("PFrom")
+		srv.writeSearchJSON(w, page, results[from:from+len(page.Pages)])
+		return
+	}
+
+	srv.servePage(w, r, page, "search")
+}
+
+// Search results as JSON
+func (srv *Server) writeSearchJSON(w http.ResponseWriter, page *Page, results []SearchResult) {
+	type item struct {
+		Title   string  `json:"title"`
+		URL     string  `json:"url"`
+		Snippet string  `json:"snippet"`
+		Score   float64 `json:"score"`
+	}
+	items := make([]item, 0, len(results))
+	for _, res := range results {
+		title := res.Page.Get("Title")
+		if title == "" {
+			title = res.Page.Get("Label")
+		}
+		items = append(items, item{
+			Title:   title,
+			URL:     res.Page.Get("URL"),
+			Snippet: string(res.Snippet),
+			Score:   res.Score,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":   page.Get("SearchTerm"),
+		"total":   page.GetInt("PTotalItems"),
+		"page":    page.GetInt("PNum"),
+		"results": items,
+	})
+}
+
+// Render page and serve it with caching headers
+// ETag is made from rendered output, so it changes with anything
+// that changes the page (content, params, templates)
+// Conditional requests (If-None-Match, If-Modified-Since) gets 304
+func (srv *Server) servePage(w http.ResponseWriter, r *http.Request, page *Page, templateID string) {
+	item := srv.renderPage(r, page, templateID)
+	if item == nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", item.etag)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	// Page param overrides site-wide default
+	cacheControl := page.Get("CacheControl")
+	if cacheControl == "" {
+		cacheControl = srv.App.cacheControl
+	}
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+
+	// Last-Modified only for pages from files
+	// Pages with content from other pages are as old as loaded content
+	var modTime time.Time
+	if page.IsSet("ModTime") {
+		modTime = page.ModTime()
+		if s := page.snapshot(); s != nil && (page.IsDir() || page.IsSet("ContentFrom")) && s.loadedAt.After(modTime) {
+			modTime = s.loadedAt
+		}
+	}
+
+	http.ServeContent(w, r, "", modTime, bytes.NewReader(item.body))
+}
+
+// Rendered page from cache or render it now
+// Returns nil on template error
+func (srv *Server) renderPage(r *http.Request, page *Page, templateID string) *cacheItem {
+	// Pages that must be read on every request
+	// can be cached only when file changes are watched
+	isCache := srv.CacheSize > 0 && (!page.IsNo("IsCache") || srv.App.watcher != nil)
+
+	key := page.Get("Lang") + " " + r.URL.RequestURI()
+	if isCache {
+		if item := srv.cache.get(key); item != nil {
+			return item
+		}
+	}
+
+	// Changes made after this are not stored
+	gen := srv.cache.generation()
+	s := page.snapshot()
+
+	var buf bytes.Buffer
+	if err := srv.Render(&buf, page, templateID); err != nil {
+		return nil
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	item := &cacheItem{
+		key:  key,
+		body: buf.Bytes(),
+		etag: `"` + hex.EncodeToString(sum[:8]) + `"`,
+	}
+
+	// Page from replaced snapshot is already stale
+	if isCache && s == srv.App.Snapshot() {
+		item.deps = srv.App.pageDeps(s, page)
+		srv.cache.add(item, gen, srv.CacheSize)
+	}
+
+	return item
 }
 
 // Run404 - handler 404
@@ -192,7 +402,11 @@ func (srv *Server) Run404(w http.ResponseWriter, r *http.Request) {
 
 // Render only layout
 // But give param for page to distinct template
-func (srv *Server) Render(w io.Writer, page *Page, templateID string) {
+func (srv *Server) Render(w io.Writer, page *Page, templateID string) error {
 	page.Set("Template", templateID)
-	srv.Templates.ExecuteTemplate(w, "layout", page)
+	tmpl, err := srv.templates()
+	if err != nil {
+		return err
+	}
+	return tmpl.ExecuteTemplate(w, "layout", page)
 }
@@ -0,0 +1,361 @@
+package mango
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Shortcode - component call in page content
+// {{< gallery dir="images/trip" >}}, {{< youtube dQw4w9WgXcQ >}}
+type Shortcode struct {
+	Name string
+
+	// Named (dir="images/trip") and positional ("0", "1"..) arguments
+	Params map[string]string
+
+	// Page where shortcode is used
+	Page *Page
+}
+
+// ShortcodeFunc - renders shortcode to HTML
+type ShortcodeFunc func(sc *Shortcode) (template.HTML, error)
+
+var (
+	// Built-in shortcodes (can be overwritten with app.AddShortcode)
+	defaultShortcodes = map[string]ShortcodeFunc{
+		"youtube":    scYouTube,
+		"gallery":    scGallery,
+		"collection": scCollection,
+		"T":          scTranslate,
+	}
+
+	reShortcode = regexp.MustCompile(`\{\{<\s*([A-Za-z][A-Za-z0-9_-]*)(.*?)>\}\}`)
+
+	// Escaped shortcode shown as is: {{</* youtube abc */>}} --> {{< youtube abc >}}
+	reShortcodeEscaped = regexp.MustCompile(`\{\{<\s*/\*(.*?)\*/\s*>\}\}`)
+
+	// Shortcode place in parsed content
+	reShortcodeHolder = regexp.MustCompile(`<!--shortcode:(\d+)-->`)
+
+	// Resized image variant: photo-480w.jpg
+	reImageVariant = regexp.MustCompile(`-\d+w\.[A-Za-z]+$`)
+)
+
+// Get - argument by name or position
+// {{< youtube id="abc" >}} and {{< youtube abc >}} are same for Get("id", 0)
+func (sc *Shortcode) Get(key string, pos int) string {
+	if val, ok := sc.Params[key]; ok {
+		return val
+	}
+	return sc.Params[strconv.Itoa(pos)]
+}
+
+// AddShortcode - add or replace shortcode
+// Not thread-safe. Add before serving pages
+func (app *Application) AddShortcode(name string, fn ShortcodeFunc) {
+	if app.shortcodes == nil {
+		app.shortcodes = make(map[string]ShortcodeFunc, 0)
+	}
+	app.shortcodes[name] = fn
+}
+
+// AddShortcode - add shortcode rendered by named template
+// Template gets *Shortcode: {{ .Params.title }}, {{ Get .Page "Title" }}
+func (srv *Server) AddShortcode(name, templateName string) {
+	srv.App.AddShortcode(name, func(sc *Shortcode) (template.HTML, error) {
+		tmpl, err := srv.templates()
+		if err != nil {
+			return "", err
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, templateName, sc); err != nil {
+			return "", err
+		}
+		return template.HTML(buf.String()), nil
+	})
+}
+
+// Shortcode by name (added or built-in)
+func (app *Application) shortcode(name string) ShortcodeFunc {
+	if app != nil {
+		if fn, ok := app.shortcodes[name]; ok {
+			return fn
+		}
+	}
+	return defaultShortcodes[name]
+}
+
+// Replace shortcodes in raw content with placeholders
+// They are rendered on every Content() call, so can show fresh data
+// Shortcodes in fenced code and inline code are left as is,
+// escaped ones ({{</* name */>}}) are unescaped everywhere
+func extractShortcodes(buf []byte) ([]byte, []*Shortcode) {
+	var shortcodes []*Shortcode
+	code := codeRanges(buf)
+
+	var out bytes.Buffer
+	last := 0
+	for _, m := range reShortcode.FindAllSubmatchIndex(buf, -1) {
+		if inRanges(code, m[0]) {
+			continue
+		}
+		sc := &Shortcode{
+			Name:   string(buf[m[2]:m[3]]),
+			Params: make(map[string]string, 0),
+		}
+
+		pos := 0
+		for _, arg := range splitQuery(string(buf[m[4]:m[5]])) {
+			if i := strings.Index(arg, "="); i > 0 && arg[0] != '"' {
+				sc.Params[arg[:i]] = unquoteValue(arg[i+1:])
+			} else {
+				sc.Params[strconv.Itoa(pos)] = unquoteValue(arg)
+				pos++
+			}
+		}
+
+		shortcodes = append(shortcodes, sc)
+		out.Write(buf[last:m[0]])
+		fmt.Fprintf(&out, "<!--shortcode:%d-->", len(shortcodes)-1)
+		last = m[1]
+	}
+	out.Write(buf[last:])
+
+	return reShortcodeEscaped.ReplaceAll(out.Bytes(), []byte("{{<$1>}}")), shortcodes
+}
+
+// Byte ranges of fenced code blocks and inline code spans in markdown
+func codeRanges(buf []byte) [][2]int {
+	var ranges [][2]int
+	fence := ""   // opening fence of current code block
+	start := 0    // of code block
+	textFrom := 0 // of text since last code block
+
+	offset := 0
+	for _, line := range bytes.SplitAfter(buf, []byte("\n")) {
+		trimmed := strings.TrimSpace(string(line))
+		switch {
+		case fence == "":
+			if f := codeFence(string(line)); f != "" {
+				ranges = append(ranges, codeSpans(buf, textFrom, offset)...)
+				fence, start = f, offset
+			}
+		case strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "":
+			// Closing fence (same char, not shorter)
+			ranges = append(ranges, [2]int{start, offset + len(line)})
+			fence, textFrom = "", offset+len(line)
+		}
+		offset += len(line)
+	}
+
+	if fence != "" {
+		// Not closed - code till end
+		return append(ranges, [2]int{start, len(buf)})
+	}
+	return append(ranges, codeSpans(buf, textFrom, len(buf))...)
+}
+
+// Opening fence of code block: ``` or ~~~ (3 or more, up to 3 spaces indent)
+// Returns "" if line does not open code block
+func codeFence(line string) string {
+	s := strings.TrimLeft(line, " ")
+	if len(line)-len(s) > 3 || len(s) < 3 || (s[0] != '`' && s[0] != '~') {
+		return ""
+	}
+	n := len(s) - len(strings.TrimLeft(s, s[:1]))
+	if n < 3 || (s[0] == '`' && strings.Contains(s[n:], "`")) {
+		return ""
+	}
+	return s[:n]
+}
+
+// Inline code spans (`code`, or with more backticks around) in buf[from:to]
+// Span is closed by backtick run of same length
+func codeSpans(buf []byte, from, to int) [][2]int {
+	var ranges [][2]int
+	runLen := func(i int) int {
+		n := 0
+		for i+n < to && buf[i+n] == '`' {
+			n++
+		}
+		return n
+	}
+
+	for i := from; i < to; {
+		switch buf[i] {
+		case '\\':
+			i += 2
+		case '`':
+			n := runLen(i)
+			end := -1
+			for j := i + n; j < to; {
+				if m := runLen(j); m > 0 {
+					if m == n {
+						end = j + m
+						break
+					}
+					j += m
+				} else {
+					j++
+				}
+			}
+			if end > 0 {
+				ranges = append(ranges, [2]int{i, end})
+				i = end
+			} else {
+				i += n
+			}
+		default:
+			i++
+		}
+	}
+	return ranges
+}
+
+// Is position in one of ranges
+func inRanges(ranges [][2]int, pos int) bool {
+	for _, r := range ranges {
+		if pos >= r[0] && pos < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Content with rendered shortcodes
+// Unknown shortcodes are shown as is, problems are reported on page file
+func (page *Page) renderShortcodes(content []byte, shortcodes []*Shortcode) []byte {
+	if !reShortcodeHolder.Match(content) {
+		return content
+	}
+
+	return reShortcodeHolder.ReplaceAllFunc(content, func(m []byte) []byte {
+		i, _ := strconv.Atoi(string(reShortcodeHolder.FindSubmatch(m)[1]))
+		if i >= len(shortcodes) {
+			return nil
+		}
+		sc := *shortcodes[i]
+		sc.Page = page
+
+		fn := page.App.shortcode(sc.Name)
+		if fn == nil {
+			page.shortcodeProblem(&sc, "unknown shortcode")
+			return []byte(html.EscapeString("{{< " + sc.Name + " >}}"))
+		}
+		out, err := fn(&sc)
+		if err != nil {
+			page.shortcodeProblem(&sc, err.Error())
+			return nil
+		}
+		return []byte(out)
+	})
+}
+
+func (page *Page) shortcodeProblem(sc *Shortcode, msg string) {
+	if s := page.snapshot(); s != nil {
+		s.diagnostics.Add(page.Get("Path"), 0, SeverityWarning, "shortcode %q: %s", sc.Name, msg)
+	}
+}
+
+// ** Built-in shortcodes
+
+// {{< youtube dQw4w9WgXcQ >}} or {{< youtube id="dQw4w9WgXcQ" title="Video" >}}
+func scYouTube(sc *Shortcode) (template.HTML, error) {
+	id := sc.Get("id", 0)
+	if id == "" {
+		return "", fmt.Errorf("no video id")
+	}
+	title := sc.Get("title", 1)
+	if title == "" {
+		title = "YouTube video"
+	}
+	return template.HTML(fmt.Sprintf(`<div class="video"><iframe src="https://www.youtube-nocookie.com/embed/%s" title="%s" frameborder="0" allowfullscreen loading="lazy"></iframe></div>`,
+		template.URLQueryEscaper(id), html.EscapeString(title))), nil
+}
+
+// {{< gallery dir="images/trip" >}}
+// Images in directory of PublicPath (resized variants skipped)
+func scGallery(sc *Shortcode) (template.HTML, error) {
+	dir := strings.Trim(sc.Get("dir", 0), "/")
+	app := sc.Page.App
+	if dir == "" || app == nil {
+		return "", fmt.Errorf("no gallery dir")
+	}
+
+	files, err := ioutil.ReadDir(app.PublicPath + "/" + dir)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || name[0] == '.' || !isImageName(name) || reImageVariant.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := sc.Page.snapshot()
+	var buf bytes.Buffer
+	buf.WriteString(`<div class="gallery">`)
+	for _, name := range names {
+		rpath := dir + "/" + name
+		url := app.fileURL(s, rpath)
+		alt := html.EscapeString(strings.TrimSuffix(name, filepath.Ext(name)))
+		srcset := ""
+		if set := app.imageSrcset(s, rpath); set != "" {
+			srcset = ` srcset="` + set + `"`
+		}
+		fmt.Fprintf(&buf, `<a href="%s"><img src="%s"%s alt="%s" loading="lazy"></a>`, url, url, srcset, alt)
+	}
+	buf.WriteString(`</div>`)
+	return template.HTML(buf.String()), nil
+}
+
+// {{< collection Tags dog >}} or {{< collection key="Tags" item="dog" sort="Title" >}}
+// Links to pages in collection item
+func scCollection(sc *Shortcode) (template.HTML, error) {
+	s := sc.Page.snapshot()
+	if s == nil {
+		return "", fmt.Errorf("page not loaded")
+	}
+	ckey := sc.Get("key", 0)
+	if s.Collection(ckey) == nil {
+		return "", fmt.Errorf("unknown collection %q", ckey)
+	}
+
+	pages := s.CollectionPages(ckey, sc.Get("item", 1))
+	pages.Sort(sc.Get("sort", 2))
+
+	var buf bytes.Buffer
+	buf.WriteString(`<ul class="collection">`)
+	for _, p := range pages {
+		fmt.Fprintf(&buf, `<li><a href="%s">%s</a></li>`, html.EscapeString(p.Get("URL")), html.EscapeString(p.Get("Title")))
+	}
+	buf.WriteString(`</ul>`)
+	return template.HTML(buf.String()), nil
+}
+
+// {{< T "Read more" >}} - translated to page language
+func scTranslate(sc *Shortcode) (template.HTML, error) {
+	return template.HTML(html.EscapeString(T(sc.Page, sc.Get("text", 0)))), nil
+}
+
+// Is image by file extension
+func isImageName(fname string) bool {
+	switch strings.ToLower(filepath.Ext(fname)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".svg":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package mango
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sitemap files under public path
+//
+//	sitemap.xml        -- all pages if they fit in one file
+//	sitemap.xml.gz     -- same gzipped (with "SitemapGzip: Yes")
+//
+// otherwise it is index of language sitemaps
+//
+//	sitemap.xml        -- index
+//	sitemap-en.xml     -- pages of language
+//	sitemap-en-2.xml   -- next part if language has too many pages
+//
+// With "SitemapGzip: Yes" language sitemaps are gzipped (sitemap-en.xml.gz)
+const sitemapFname = "sitemap"
+
+// URLs in one sitemap file (limit of sitemaps protocol)
+var maxSitemapURLs = 50000
+
+// Allowed "ChangeFreq" param values
+var sitemapChangeFreqs = map[string]bool{
+	"always": true, "hourly": true, "daily": true, "weekly": true,
+	"monthly": true, "yearly": true, "never": true,
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapRef `xml:"sitemap"`
+}
+
+type sitemapRef struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	XHTML   string       `xml:"xmlns:xhtml,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string             `xml:"loc"`
+	LastMod    string             `xml:"lastmod,omitempty"`
+	ChangeFreq string             `xml:"changefreq,omitempty"`
+	Priority   string             `xml:"priority,omitempty"`
+	Alternates []sitemapAlternate `xml:"xhtml:link"`
+
+	lang    string
+	key     string // pages with same key are translations
+	modTime time.Time
+}
+
+// Translated page: <xhtml:link rel="alternate" hreflang="lv" href="..."/>
+type sitemapAlternate struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// Sitemap entries of every language (sorted by url)
+func (app *Application) sitemapURLs(s *Snapshot) map[string][]*sitemapURL {
+	langURLs := make(map[string][]*sitemapURL, 0)
+
+	s.slugPages.RLock()
+	for slug, p := range s.slugPages.m {
+		if slug[0] == '.' || p.IsYes("IsUnlisted") || p.IsEqual("IsSitemap", _No) || p.IsSet("Redirect") {
+			// Unlisted, redirect and some other pages are not in sitemap
+			continue
+		}
+
+		u := &sitemapURL{
+			Loc:     p.AbsoluteURL(),
+			LastMod: p.ModTime().Format(time.RFC3339),
+			lang:    p.Get("Lang"),
+			key:     p.Get("TranslationKey"),
+			modTime: p.ModTime(),
+		}
+		if f, err := strconv.ParseFloat(p.Get("Priority"), 64); err == nil && f >= 0 && f <= 1 {
+			u.Priority = strconv.FormatFloat(f, 'f', 1, 64)
+		}
+		if freq := strings.ToLower(p.Get("ChangeFreq")); sitemapChangeFreqs[freq] {
+			u.ChangeFreq = freq
+		}
+		if p.Get("Level") == "0" {
+			// Language roots are translations of each other
+			u.lang, u.key = slug, ".root"
+		}
+		langURLs[u.lang] = append(langURLs[u.lang], u)
+	}
+	s.slugPages.RUnlock()
+
+	// Translations by key
+	translations := make(map[string][]*sitemapURL, 0)
+	for _, urls := range langURLs {
+		for _, u := range urls {
+			if u.key != "" {
+				translations[u.key] = append(translations[u.key], u)
+			}
+		}
+	}
+	for _, urls := range translations {
+		if len(urls) < 2 {
+			continue
+		}
+		sort.Slice(urls, func(i, j int) bool {
+			return urls[i].lang < urls[j].lang
+		})
+		for _, u := range urls {
+			for _, u2 := range urls {
+				u.Alternates = append(u.Alternates, sitemapAlternate{Rel: "alternate", Hreflang: u2.lang, Href: u2.Loc})
+			}
+		}
+	}
+
+	for _, urls := range langURLs {
+		sort.Slice(urls, func(i, j int) bool {
+			return urls[i].Loc < urls[j].Loc
+		})
+	}
+	return langURLs
+}
+
+// Write sitemap under dir (public path or export)
+// Index and language sitemaps only if urls don't fit in one file
+// Old sitemap files are removed (language or parts can be gone)
+func (app *Application) createSitemap(s *Snapshot, dir string) {
+	old, _ := filepath.Glob(dir + "/" + sitemapFname + "-*.xml*")
+	for _, fpath := range append(old, dir+"/"+sitemapFname+".xml.gz") {
+		os.Remove(fpath)
+	}
+
+	langURLs := app.sitemapURLs(s)
+	langs := make([]string, 0, len(langURLs))
+	count := 0
+	for lang, urls := range langURLs {
+		langs = append(langs, lang)
+		count += len(urls)
+	}
+	sort.Strings(langs)
+
+	// All in one file
+	if count <= maxSitemapURLs {
+		var urls []*sitemapURL
+		for _, lang := range langs {
+			urls = append(urls, langURLs[lang]...)
+		}
+		set, _ := newSitemapURLSet(urls)
+		if err := writeSitemap(dir+"/"+sitemapFname+".xml", set, false); err != nil {
+			s.diagnostics.Add(dir, 0, SeverityError, "can't write sitemap: %v", err)
+			return
+		}
+		if app.isSitemapGzip {
+			if err := writeSitemap(dir+"/"+sitemapFname+".xml.gz", set, true); err != nil {
+				s.diagnostics.Add(dir, 0, SeverityError, "can't write sitemap: %v", err)
+			}
+		}
+		return
+	}
+
+	ext := ".xml"
+	if app.isSitemapGzip {
+		ext = ".xml.gz"
+	}
+
+	var index sitemapIndex
+	for _, lang := range langs {
+		urls := langURLs[lang]
+		parts := (len(urls) + maxSitemapURLs - 1) / maxSitemapURLs
+		for i := 0; i < parts; i++ {
+			end := (i + 1) * maxSitemapURLs
+			if end > len(urls) {
+				end = len(urls)
+			}
+
+			fname := sitemapFname + "-" + lang + ext
+			if parts > 1 {
+				fname = fmt.Sprintf("%s-%s-%d%s", sitemapFname, lang, i+1, ext)
+			}
+
+			set, lastMod := newSitemapURLSet(urls[i*maxSitemapURLs : end])
+			if err := writeSitemap(dir+"/"+fname, set, app.isSitemapGzip); err != nil {
+				s.diagnostics.Add(dir, 0, SeverityError, "can't write sitemap: %v", err)
+				return
+			}
+
+			ref := sitemapRef{Loc: app.Domain + "/" + fname}
+			if !lastMod.IsZero() {
+				ref.LastMod = lastMod.Format(time.RFC3339)
+			}
+			index.Sitemaps = append(index.Sitemaps, ref)
+		}
+	}
+
+	if err := writeSitemap(dir+"/"+sitemapFname+".xml", index, false); err != nil {
+		s.diagnostics.Add(dir, 0, SeverityError, "can't write sitemap: %v", err)
+	}
+}
+
+// Sitemap of given urls and time of newest page in it
+func newSitemapURLSet(urls []*sitemapURL) (sitemapURLSet, time.Time) {
+	set := sitemapURLSet{XHTML: "http://www.w3.org/1999/xhtml"}
+	var lastMod time.Time
+	for _, u := range urls {
+		set.URLs = append(set.URLs, *u)
+		if u.modTime.After(lastMod) {
+			lastMod = u.modTime
+		}
+	}
+	return set, lastMod
+}
+
+// Write xml file (gzipped if asked)
+func writeSitemap(fpath string, v interface{}, isGzip bool) error {
+	var buf bytes.Buffer
+	if err := writeXML(&buf, v); err != nil {
+		return err
+	}
+	if !isGzip {
+		return ioutil.WriteFile(fpath, buf.Bytes(), 0644)
+	}
+
+	var gzBuf bytes.Buffer
+	zw := gzip.NewWriter(&gzBuf)
+	zw.Write(buf.Bytes())
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fpath, gzBuf.Bytes(), 0644)
+}
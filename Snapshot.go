@@ -0,0 +1,236 @@
+package mango
+
+import "time"
+
+// Snapshot - loaded content (page tree, slugs, collections, translations)
+// Reload builds new snapshot and application swaps it in atomically,
+// so readers always see complete content.
+// Take snapshot once and use it for whole request
+// to get consistent content even if reload happens meanwhile
+type Snapshot struct {
+	// Page tree
+	Pages PageList
+
+	// Easy count overall pages and detect duplicates
+	// map[Slug]Page
+	slugPages *PageMap
+
+	// Collectables - pages that are collected
+	// Example: "Tag: dog, cat, mouse" --> every tag will point to one *Page
+	// Case sensitive
+	collections map[string]*Collection
+
+	// Translations
+	// If no need to create new .md file but need translate one string
+	// translations[lv][Hello] = "Labdien!"
+	translations map[string]map[string]string
+
+	// Problems found while loading this content
+	diagnostics *Diagnostics
+
+	// Assets (images, pdf..) by content-relative path
+	// assets["1_en/news/logo.png"] = "/abs/content/1_en/news/logo.png"
+	// When assets are moved, by public path to detect collisions
+	assets map[string]string
+
+	// Content-relative paths of assets by file name
+	// assetNames["logo.png"] = ["1_en/logo.png", "lv/logo.png"]
+	assetNames map[string][]string
+
+	// Full-text search index
+	search *searchIndex
+
+	// Loaded only to check content (lint)
+	// Files are not changed
+	isDryRun bool
+
+	// Content is loaded (empty snapshot of opened application is not)
+	isLoaded bool
+
+	// When content was loaded (or reloaded)
+	// Pages made from other pages can't be older
+	loadedAt time.Time
+}
+
+// Create empty snapshot with collections from config
+func newSnapshot(ckeys []string) *Snapshot {
+	s := &Snapshot{
+		slugPages:    NewPageMap(),
+		collections:  make(map[string]*Collection, 0),
+		translations: make(map[string]map[string]string, 0),
+		diagnostics:  NewDiagnostics(),
+		assets:       make(map[string]string, 0),
+		assetNames:   make(map[string][]string, 0),
+		search:       newSearchIndex(),
+		loadedAt:     time.Now(),
+	}
+
+	// Add empty to later know what we are collecting (in app.LoadContent)
+	for _, ckey := range ckeys {
+		s.collections[ckey] = NewCollection()
+	}
+
+	return s
+}
+
+// Copy of snapshot to be changed (partial reload)
+// Every page is copied too, so previous snapshot
+// stays untouched for readers that still hold it
+func (s *Snapshot) clone() *Snapshot {
+	s2 := &Snapshot{
+		slugPages:    NewPageMap(),
+		collections:  make(map[string]*Collection, 0),
+		translations: s.translations,
+		diagnostics:  s.diagnostics.clone(),
+		assets:       make(map[string]string, len(s.assets)),
+		assetNames:   make(map[string][]string, len(s.assetNames)),
+		isDryRun:     s.isDryRun,
+		isLoaded:     s.isLoaded,
+		loadedAt:     time.Now(),
+	}
+
+	for rpath, fpath := range s.assets {
+		s2.assets[rpath] = fpath
+	}
+	for fname, rpaths := range s.assetNames {
+		s2.assetNames[fname] = append([]string{}, rpaths...)
+	}
+
+	// Old page --> copy in new snapshot
+	copies := make(map[*Page]*Page, 0)
+	s2.Pages = s.copyPages(s2, s.Pages, nil, copies)
+
+	// Unlisted pages are not in tree (parent can be unlisted too)
+	var copyPage func(p *Page) *Page
+	copyPage = func(p *Page) *Page {
+		if p == nil || copies[p] != nil {
+			return copies[p]
+		}
+		parent := copyPage(p.Parent)
+		if copies[p] == nil {
+			s.copyPages(s2, PageList{p}, parent, copies)
+		}
+		return copies[p]
+	}
+
+	s.slugPages.RLock()
+	for key, p := range s.slugPages.m {
+		s2.slugPages.m[key] = copyPage(p)
+	}
+	s.slugPages.RUnlock()
+
+	for ckey, c := range s.collections {
+		c2 := NewCollection()
+		c.RLock()
+		for key, pages := range c.m {
+			for _, p := range pages {
+				if p2 := copies[p]; p2 != nil {
+					c2.m[key] = append(c2.m[key], p2)
+				}
+			}
+		}
+		c.RUnlock()
+		s2.collections[ckey] = c2
+	}
+
+	s2.search = s.search.clone(copies)
+
+	return s2
+}
+
+// Copy pages (with sub-pages) to snapshot s2
+// Content is shared (it is replaced, never changed in place)
+func (s *Snapshot) copyPages(s2 *Snapshot, pages PageList, parent *Page, copies map[*Page]*Page) PageList {
+	var pages2 PageList
+	for _, p := range pages {
+		p2 := copies[p]
+		if p2 == nil {
+			p.RLock()
+			p2 = &Page{
+				App:        p.App,
+				snap:       s2,
+				content:    p.content,
+				shortcodes: p.shortcodes,
+				headings:   p.headings,
+				params:     make(map[string]string, len(p.params)),
+				Parent:     parent,
+			}
+			for key, val := range p.params {
+				p2.params[key] = val
+			}
+			subPages := p.Pages
+			p.RUnlock()
+
+			copies[p] = p2
+			p2.Pages = s.copyPages(s2, subPages, p2, copies)
+		}
+		pages2 = append(pages2, p2)
+	}
+	return pages2
+}
+
+// Page - get one page by given slug.
+// Slug must be equal and is case-sensitive
+func (s *Snapshot) Page(slug string) *Page {
+	return s.slugPages.Get(slug)
+}
+
+// PageCount - total count of pages
+func (s *Snapshot) PageCount() int {
+	return s.slugPages.Len()
+}
+
+// Collection - get collection by ckey
+func (s *Snapshot) Collection(ckey string) *Collection {
+	c := s.collections[ckey]
+
+	// Try to get plural forms of given ckey
+	if c == nil {
+		switch {
+		case ckey == "Category":
+			ckey = "Categories"
+		case ckey == "Keyword":
+			ckey = "Keywords"
+		case ckey == "Tag":
+			ckey = "Tags"
+		}
+		c = s.collections[ckey]
+	}
+
+	return c
+}
+
+// CollectionCount - total count of collections
+func (s *Snapshot) CollectionCount() int {
+	return len(s.collections)
+}
+
+// CollectionPages - shorthand to get collection subitems
+// Avoiding errors. Without it need to use: s.Collection(ckey).Get(csubkey)
+// But there could be no ckey collection and that results in go-lang error
+func (s *Snapshot) CollectionPages(ckey, csubkey string) PageList {
+	if c := s.Collection(ckey); c != nil {
+		return c.Get(csubkey)
+	}
+	return nil
+}
+
+// IsValidLang - is given language is valid in snapshot scope
+func (s *Snapshot) IsValidLang(lang string) bool {
+	_, isValid := s.translations[lang]
+	return isValid
+}
+
+// Translate string to given language
+// Returns given string if no translation found
+func (s *Snapshot) Translate(lang, str string) string {
+	if translated, ok := s.translations[lang][str]; ok {
+		return translated
+	}
+	return str
+}
+
+// Diagnostics - problems found while loading this content
+func (s *Snapshot) Diagnostics() []Diagnostic {
+	return s.diagnostics.List()
+}
@@ -0,0 +1,117 @@
+package mango
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Content before this marker is page summary
+const summaryMarker = "<!--more-->"
+
+const (
+	// Summary length when no marker (page param "SummaryWords" overrides)
+	defaultSummaryWords = 70
+
+	// Description for meta tags is cut from summary
+	descriptionLength = 160
+
+	// For ReadingTime
+	wordsPerMinute = 200
+)
+
+// Params computed from page content
+// Summary only if not set in page file
+var contentStatParams = []string{"WordCount", "ReadingTime", "Summary"}
+
+// Set WordCount, ReadingTime and Summary params from rendered content
+func setContentStats(params map[string]string, content []byte) {
+	text := htmlToText(string(content))
+	words := len(strings.Fields(text))
+	params["WordCount"] = strconv.Itoa(words)
+	params["ReadingTime"] = strconv.Itoa((words + wordsPerMinute - 1) / wordsPerMinute) // minutes
+
+	if params["Summary"] == "" {
+		summary := ""
+		if pos := bytes.Index(content, []byte(summaryMarker)); pos >= 0 {
+			summary = htmlToText(string(content[:pos]))
+		} else {
+			n, err := strconv.Atoi(params["SummaryWords"])
+			if err != nil || n <= 0 {
+				n = defaultSummaryWords
+			}
+			summary = cutWords(text, n)
+		}
+		if summary != "" {
+			params["Summary"] = summary
+		}
+	}
+}
+
+// Content params from page of reloaded file
+func (page *Page) copyContentStats(p2 *Page) {
+	for _, key := range contentStatParams {
+		if p2.IsSet(key) {
+			page.Set(key, p2.Get(key))
+		} else {
+			page.RemoveParam(key)
+		}
+	}
+}
+
+// First n words of text ("…" added if cut)
+func cutWords(text string, n int) string {
+	words := strings.Fields(text)
+	if len(words) <= n {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(words[:n], " ") + "…"
+}
+
+// Text not longer than max chars, cut at word end ("…" added if cut)
+func cutText(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	cut := string(runes[:max])
+	if pos := strings.LastIndex(cut, " "); pos > 0 {
+		cut = cut[:pos]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+// Summary - teaser of page content
+// Content before <!--more--> marker (as HTML) or "Summary" param
+func (page *Page) Summary() template.HTML {
+	content := page.Content()
+	if pos := bytes.Index(content, []byte(summaryMarker)); pos >= 0 {
+		before := bytes.TrimSpace(content[:pos])
+		// Marker inside paragraph: <p><!--more--></p>
+		before = bytes.TrimSuffix(before, []byte("<p>"))
+		return template.HTML(bytes.TrimSpace(before))
+	}
+	return template.HTML(html.EscapeString(page.Get("Summary")))
+}
+
+// Description - for meta tags
+// "Description" param (page or .defaults) or cut from summary
+func (page *Page) Description() string {
+	if desc := page.Get("Description"); desc != "" {
+		return desc
+	}
+	return cutText(page.Get("Summary"), descriptionLength)
+}
+
+// WordCount - words in page content
+func (page *Page) WordCount() int {
+	return page.GetInt("WordCount")
+}
+
+// ReadingTime - minutes to read page content
+func (page *Page) ReadingTime() int {
+	return page.GetInt("ReadingTime")
+}
@@ -0,0 +1,126 @@
+package mango
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+)
+
+// Heading - heading in page content (with id)
+type Heading struct {
+	Level int
+	ID    string
+	Title string // plain text
+
+	// Lower level headings under this one
+	Children []*Heading
+}
+
+var (
+	reHeading   = regexp.MustCompile(`(?s)<h([1-6])([^>]*)>(.*?)</h[1-6]>`)
+	reHeadingID = regexp.MustCompile(`\bid="([^"]+)"`)
+)
+
+// Headings with id from rendered content (in order)
+func extractHeadings(content []byte) []Heading {
+	var headings []Heading
+	for _, m := range reHeading.FindAllSubmatch(content, -1) {
+		id := reHeadingID.FindSubmatch(m[2])
+		if id == nil {
+			continue
+		}
+		headings = append(headings, Heading{
+			Level: int(m[1][0] - '0'),
+			ID:    html.UnescapeString(string(id[1])),
+			Title: htmlToText(string(m[3])),
+		})
+	}
+	return headings
+}
+
+// Permalink anchor at the end of every heading with id
+// <h2 id="intro">Intro <a class="anchor" href="#intro" aria-hidden="true">#</a></h2>
+func addHeadingAnchors(content []byte) []byte {
+	return reHeading.ReplaceAllFunc(content, func(m []byte) []byte {
+		sm := reHeading.FindSubmatch(m)
+		id := reHeadingID.FindSubmatch(sm[2])
+		if id == nil {
+			return m
+		}
+		return []byte(fmt.Sprintf(`<h%s%s>%s <a class="anchor" href="#%s" aria-hidden="true">#</a></h%s>`,
+			sm[1], sm[2], sm[3], id[1], sm[1]))
+	})
+}
+
+// Flat headings to tree
+// Heading goes under nearest previous heading with lower level
+func headingTree(headings []Heading) []*Heading {
+	var roots, stack []*Heading
+	for i := range headings {
+		h := headings[i] // copy
+		h.Children = nil
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, &h)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, &h)
+		}
+		stack = append(stack, &h)
+	}
+	return roots
+}
+
+// Headings of content (set together with content)
+func (page *Page) setHeadings(headings []Heading) {
+	page.Lock()
+	page.headings = headings
+	page.Unlock()
+}
+
+// Headings - heading tree of page content
+func (page *Page) Headings() []*Heading {
+	page.RLock()
+	defer page.RUnlock()
+
+	return headingTree(page.headings)
+}
+
+// TOC - table of contents as nested list
+// Only headings from minLevel to maxLevel (0 - no limit)
+func (page *Page) TOC(minLevel, maxLevel int) template.HTML {
+	page.RLock()
+	var headings []Heading
+	for _, h := range page.headings {
+		if h.Level >= minLevel && (maxLevel <= 0 || h.Level <= maxLevel) {
+			headings = append(headings, h)
+		}
+	}
+	page.RUnlock()
+
+	if len(headings) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<nav class="toc">`)
+	writeTOC(&buf, headingTree(headings))
+	buf.WriteString(`</nav>`)
+	return template.HTML(buf.String())
+}
+
+func writeTOC(buf *bytes.Buffer, headings []*Heading) {
+	buf.WriteString("<ul>")
+	for _, h := range headings {
+		fmt.Fprintf(buf, `<li><a href="#%s">%s</a>`, html.EscapeString(h.ID), html.EscapeString(h.Title))
+		if len(h.Children) > 0 {
+			writeTOC(buf, h.Children)
+		}
+		buf.WriteString("</li>")
+	}
+	buf.WriteString("</ul>")
+}
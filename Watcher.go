@@ -0,0 +1,334 @@
+package mango
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher - watches ContentPath and reloads content in background
+// With {Hash} in FileURL PublicPath is watched too (pages get fresh hashes)
+// Template changes reload server templates (see Server.ReloadTemplates)
+// Bursts of changes (editor saves, git checkout) are collected
+// and only one reload is done after changes become quiet
+type Watcher struct {
+	// Link to application
+	app *Application
+
+	// Quiet period after last change before reload starts
+	Delay time.Duration
+
+	// How often ContentPath is scanned when polling is used
+	Interval time.Duration
+
+	// Filesystem events (nil if polling)
+	fsw *fsnotify.Watcher
+
+	// Last known signatures of content and templates (polling only)
+	signature         uint64
+	templateSignature uint64
+
+	// Changed paths since last reload
+	// isAll - unknown which (polling), whole content is reloaded
+	paths       map[string]bool
+	isAll       bool
+	isTemplates bool
+	mu          sync.Mutex
+
+	// Signal that something changed
+	chChanged chan bool
+
+	chStop   chan bool
+	chDone   chan bool
+	stopOnce sync.Once
+}
+
+// Watch - start watching ContentPath for changes
+// Uses filesystem events (inotify) and falls back to polling
+// if events are not available (or poll=true is given)
+func (app *Application) Watch(poll bool) (*Watcher, error) {
+	if _, err := os.Stat(app.ContentPath); err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		app:       app,
+		Delay:     app.watchDelay,
+		Interval:  app.watchInterval,
+		chChanged: make(chan bool, 1),
+		chStop:    make(chan bool),
+		chDone:    make(chan bool),
+	}
+
+	if w.Delay <= 0 {
+		w.Delay = 300 * time.Millisecond
+	}
+	if w.Interval <= 0 {
+		w.Interval = time.Second
+	}
+
+	if !poll {
+		if fsw, err := fsnotify.NewWatcher(); err == nil {
+			w.fsw = fsw
+			if err := w.addDirs(append(w.roots(), w.templateRoots()...)...); err != nil {
+				// Too many dirs for inotify limits etc.
+				// polling will do the job
+				fsw.Close()
+				w.fsw = nil
+			}
+		}
+	}
+
+	if w.fsw != nil {
+		go w.listen()
+	} else {
+		w.signature = w.scan(w.roots()...)
+		w.templateSignature = w.scan(w.templateRoots()...)
+		go w.poll()
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+// IsPolling - is watcher using polling instead of filesystem events
+func (w *Watcher) IsPolling() bool {
+	return w.fsw == nil
+}
+
+// Stop watching
+// Reload that is in progress is finished before return
+// Safe to call more than once
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.chStop)
+		if w.fsw != nil {
+			w.fsw.Close()
+		}
+	})
+	<-w.chDone
+}
+
+// Debounce changes and reload
+// Only this goroutine reloads, so reloads never overlap
+func (w *Watcher) run() {
+	timer := time.NewTimer(w.Delay)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.chStop:
+			timer.Stop()
+			close(w.chDone)
+			return
+
+		case <-w.chChanged:
+			// Wait again for quiet period
+			timer.Stop()
+			timer.Reset(w.Delay)
+
+		case <-timer.C:
+			w.mu.Lock()
+			paths, isAll, isTemplates := w.paths, w.isAll, w.isTemplates
+			w.paths, w.isAll, w.isTemplates = nil, false, false
+			w.mu.Unlock()
+
+			// Before content, so reloaded pages are rendered with new templates
+			if isTemplates && w.app.Server != nil {
+				// Broken template (saved in the middle of edit)
+				// keeps old ones until fixed
+				w.app.Server.ReloadTemplates()
+			}
+
+			if isAll {
+				w.app.LoadContent()
+			} else if len(paths) > 0 {
+				// Reload only changed files
+				fpaths := make([]string, 0, len(paths))
+				for fpath := range paths {
+					fpaths = append(fpaths, fpath)
+				}
+				w.app.ReloadPath(fpaths...)
+			}
+		}
+	}
+}
+
+// Remember changed path for partial reload
+func (w *Watcher) changedPath(fpath string) {
+	w.mu.Lock()
+	if w.isTemplate(fpath) {
+		w.isTemplates = true
+	} else {
+		if w.paths == nil {
+			w.paths = make(map[string]bool, 0)
+		}
+		w.paths[fpath] = true
+	}
+	w.mu.Unlock()
+
+	w.changed()
+}
+
+// Mark that something changed
+// Never blocks. One pending signal is enough
+func (w *Watcher) changed() {
+	select {
+	case w.chChanged <- true:
+	default:
+	}
+}
+
+// Receive filesystem events
+func (w *Watcher) listen() {
+	for {
+		select {
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+
+			// New directories must be watched too
+			if ev.Op&fsnotify.Create == fsnotify.Create {
+				if finfo, err := os.Stat(ev.Name); err == nil && finfo.IsDir() {
+					w.addDirs(ev.Name)
+				}
+			}
+
+			if w.isRelevant(ev.Name, ev.Op) {
+				w.changedPath(ev.Name)
+			}
+
+		case _, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// Scan ContentPath every interval and compare signatures
+func (w *Watcher) poll() {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.chStop:
+			return
+		case <-ticker.C:
+			if sig := w.scan(w.roots()...); sig != w.signature {
+				w.signature = sig
+				w.mu.Lock()
+				w.isAll = true
+				w.mu.Unlock()
+				w.changed()
+			}
+			if sig := w.scan(w.templateRoots()...); sig != w.templateSignature {
+				w.templateSignature = sig
+				w.mu.Lock()
+				w.isTemplates = true
+				w.mu.Unlock()
+				w.changed()
+			}
+		}
+	}
+}
+
+// Signature of all relevant files under given directories
+// Based on path, size and modification time
+func (w *Watcher) scan(roots ...string) uint64 {
+	h := fnv.New64a()
+	for _, root := range roots {
+		filepath.Walk(root, func(fpath string, finfo os.FileInfo, err error) error {
+			if err != nil || finfo.IsDir() || !w.isRelevant(fpath, fsnotify.Write) {
+				return nil
+			}
+			fmt.Fprint(h, fpath, finfo.Size(), finfo.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return h.Sum64()
+}
+
+// Watched directories
+// PublicPath only if file urls have content hash
+func (w *Watcher) roots() []string {
+	roots := []string{w.app.ContentPath}
+	if w.app.isFileHash() {
+		if _, err := os.Stat(w.app.PublicPath); err == nil {
+			roots = append(roots, w.app.PublicPath)
+		}
+	}
+	return roots
+}
+
+// Template directory (if there is one)
+func (w *Watcher) templateRoots() []string {
+	if _, err := os.Stat(w.app.templatePath()); err != nil {
+		return nil
+	}
+	return []string{w.app.templatePath()}
+}
+
+// Is file in template directory
+func (w *Watcher) isTemplate(fpath string) bool {
+	return strings.HasPrefix(fpath, w.app.templatePath()+"/")
+}
+
+// Add given directories and all sub-directories to watch list
+func (w *Watcher) addDirs(roots ...string) error {
+	for _, root := range roots {
+		err := filepath.Walk(root, func(fpath string, finfo os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if finfo.IsDir() {
+				return w.fsw.Add(fpath)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Is this change must reload content
+func (w *Watcher) isRelevant(fpath string, op fsnotify.Op) bool {
+	fname := filepath.Base(fpath)
+
+	// Editor backup and swap files
+	if strings.HasSuffix(fname, "~") || strings.HasSuffix(fname, ".swp") || strings.HasPrefix(fname, ".#") {
+		return false
+	}
+
+	// Only templates themselves
+	if w.isTemplate(fpath) {
+		return filepath.Ext(fname) == ".tmpl"
+	}
+
+	// Files written by mango itself (sitemap, feeds..) do not change pages
+	if rpath := w.app.publicRelPath(fpath); rpath != "" {
+		return w.app.isFileHash() && !isGeneratedFile(rpath)
+	}
+
+	// Assets are moved out of content on every load
+	// Do not reload because of that
+	ext := strings.ToLower(filepath.Ext(fname))
+	isRemoved := op&(fsnotify.Remove|fsnotify.Rename) != 0
+	isMoved := w.app.assetMode == assetsMove || w.app.assetMode == ""
+	if isMoved && isRemoved && ext != _Md && ext != "" && fname[0] != '.' {
+		return false
+	}
+
+	return true
+}
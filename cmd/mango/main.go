@@ -0,0 +1,102 @@
+// Command mango - tools for mango sites
+//
+// Run from site directory (where ".mango" config file is)
+// or give it with -path flag.
+//
+//	mango export [-path .] [-out static]
+//	mango lint [-path .]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"bitbucket.org/briiC/mango-v3"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "export":
+		runExport(os.Args[2:])
+	case "lint":
+		runLint(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "\tmango export [-path .] [-out static]\trender all pages to static files")
+	fmt.Fprintln(os.Stderr, "\tmango lint [-path .]\t\t\tcheck content for problems (exit status 1 if found)")
+}
+
+// Open site from given path without changing any files
+// (assets are not moved, sitemap and feeds not written, no watcher)
+// Relative paths in ".mango" are relative to site path
+func openApp(path string) *mango.Application {
+	if err := os.Chdir(path); err != nil {
+		log.Fatal(err)
+	}
+	app, err := mango.OpenApplication(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return app
+}
+
+// mango export
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	path := fs.String("path", ".", "site directory (with .mango config file)")
+	out := fs.String("out", "static", "directory where to write files")
+	fs.Parse(args)
+
+	// Relative to where command is run, not to site
+	outDir, err := filepath.Abs(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app := openApp(*path)
+	app.LoadContentDryRun()
+	srv := mango.NewAppServer(app, 0)
+
+	if err := srv.Export(outDir); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Exported %d pages to %s", app.PageCount(), outDir)
+}
+
+// mango lint
+// Content is not changed (assets not moved, no sitemap)
+func runLint(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	path := fs.String("path", ".", "site directory (with .mango config file)")
+	fs.Parse(args)
+
+	app := openApp(*path)
+
+	problems := app.Lint()
+	for _, d := range problems {
+		// Shorter paths relative to site
+		if rpath, err := filepath.Rel(app.BinPath(), d.Path); err == nil {
+			d.Path = rpath
+		}
+		fmt.Println(d)
+	}
+
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problem(s) found\n", len(problems))
+		os.Exit(1)
+	}
+}
@@ -3,7 +3,9 @@ package mango
 import (
 	"errors"
 	"fmt"
+	"html"
 	"log"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +22,13 @@ func ToASCII(str string) string {
 // return merged map
 // NOT THREAD-SAFE. Use this testing heavily.
 // Safe if using in application init phase.
+//
+// Operators in more important map:
+//
+//	+Key: val -- append to inherited list
+//	-Key: val -- remove items from inherited list
+//	!Key      -- don't inherit (also nested: !Social --> Social.Github)
+//	Key?: val -- only if not inherited (see resolveParamOps)
 func mergeParams(mainMap map[string]string, maps ...map[string]string) map[string]string {
 	//copy mainMap for concurrent write
 	m := make(map[string]string, 0)
@@ -29,10 +38,23 @@ func mergeParams(mainMap map[string]string, maps ...map[string]string) map[strin
 
 	// actual merge
 	for _, submap := range maps {
+		// Nested values already set (Gallery.0.Src)
+		parents := nestedParents(m)
+
 		for key, val := range submap {
 
+			// Cleared by !Key
+			if isClearedParam(m, key) {
+				continue
+			}
+
 			// Create if empty
-			if _, isKey := m[key]; !isKey {
+			if _, isKey := m[key]; !isKey && !isNestedSet(m, parents, key) {
+				// Remove -Key: Val
+				if mval, isKey := m["-"+key]; isKey {
+					val = removeItems(val, mval)
+					delete(m, "-"+key)
+				}
 				m[key] = val
 			}
 
@@ -46,12 +68,29 @@ func mergeParams(mainMap map[string]string, maps ...map[string]string) map[strin
 	return m
 }
 
+// Datetimes with zone (RFC3339) from Hugo, Jekyll, TOML front matter
+var timeZoneLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02T15:04:05", // TOML local datetime
+}
+
 // ToTime - datetime string to time type
 // Parse any custom string
 // 2006-01-02 15:04:05
 // 01 - month
 // 02 - day
+// Also RFC3339: 2006-01-02T15:04:05Z, 2006-01-02 15:04:05+02:00
 func ToTime(s string) (time.Time, error) {
+	for _, layout := range timeZoneLayouts {
+		if dt, err := time.Parse(layout, s); err == nil {
+			return dt, nil
+		}
+	}
+
 	sLen := len(s)
 	dtNow := time.Now()
 
@@ -140,6 +179,21 @@ func ToTime(s string) (time.Time, error) {
 	return dt, err
 }
 
+// Parts of html removed in plain text
+var (
+	reHTMLSkip = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	reHTMLTag  = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// Html to plain text (tags removed, entities decoded, spaces collapsed)
+// <p>Fish &amp; chips</p> --> Fish & chips
+func htmlToText(s string) string {
+	s = reHTMLSkip.ReplaceAllString(s, " ")
+	s = reHTMLTag.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
 // Print map in human readable format
 func printMap(fname string, m map[string]string) {
 	log.Println("---", fname, "--------------------------------------------")
@@ -3,6 +3,7 @@ package mango
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -13,7 +14,7 @@ import (
 )
 
 // FileToParams - Read file contents and convert to map of params
-// We do not check for error because we only want to get/or not file
+// Problems are not reported. Missing file gives empty params
 // Importance order:
 // 1. Content params
 // 2. Filename params
@@ -21,6 +22,11 @@ import (
 // 4 -n. (up n depth) .subdefaults
 // 5. .mango config file params
 func FileToParams(fpath string) map[string]string {
+	return fileToParams(fpath, nil)
+}
+
+// Same as FileToParams but problems are reported to diag
+func fileToParams(fpath string, diag *Diagnostics) map[string]string {
 	// get given filepath directory path
 	pwd, _ := filepath.Abs(filepath.Dir(fpath))
 
@@ -61,9 +67,14 @@ func FileToParams(fpath string) map[string]string {
 	// if _, err := os.Stat("/path/to/whatever"); os.IsNotExist(err) {
 
 	// Raw file contents
-	// Not checking for read error. If can't read empty content
-	buf, _ := ioutil.ReadFile(fpath)
+	// If can't read empty content
+	// (directories without .dir file are ok)
+	buf, err := ioutil.ReadFile(fpath)
+	if err != nil && !os.IsNotExist(err) {
+		diag.Add(fpath, 0, SeverityError, "can't read file: %v", err)
+	}
 	var bufHeader, bufContent []byte
+	format := "" // mango "Key: Value" header
 
 	// Split raw buf to variables
 	sep := []byte("\n+++") // it's no problem to leave \n if front of content
@@ -71,6 +82,10 @@ func FileToParams(fpath string) map[string]string {
 		// Not .md file, so use as params file
 		bufHeader = buf
 
+	} else if fm, header, content := splitFrontMatter(buf); fm != "" {
+		// YAML (---) or TOML (+++) front matter
+		format, bufHeader, bufContent = fm, header, content
+
 	} else if bytes.Index(buf, sep) >= 0 {
 		// have header and content separated by content separator
 		arr := bytes.SplitN(buf, sep, 2)
@@ -82,7 +97,12 @@ func FileToParams(fpath string) map[string]string {
 	}
 
 	// ** Params
-	params := bufToParams(bufHeader, true) // first assign what we can from buf
+	var params map[string]string
+	if format != "" {
+		params = frontMatterToParams(format, bufHeader, fpath, diag)
+	} else {
+		params = bufToParams(bufHeader, true, fpath, diag) // first assign what we can from buf
+	}
 
 	// ** Content
 	bufContent = bytes.TrimSpace(bufContent)
@@ -100,7 +120,7 @@ func FileToParams(fpath string) map[string]string {
 	if params2["Ext"] == _Md || finfo.IsDir() {
 
 		// Same depth .defaults
-		params3 = FileToParams(pwd + "/.defaults")
+		params3 = fileToParams(pwd+"/.defaults", diag)
 
 		// Up level .subdefaults (until can't found)
 		subfilepath := pwd
@@ -108,7 +128,7 @@ func FileToParams(fpath string) map[string]string {
 		subparams := make(map[string]string, 0)
 	SUB:
 		subfilepath, _ = filepath.Abs(subfilepath + "/../") //one up
-		_subparams := FileToParams(subfilepath + "/.subdefaults")
+		_subparams := fileToParams(subfilepath+"/.subdefaults", diag)
 		if len(_subparams) > 0 {
 			subparams = mergeParams(subparams, _subparams)
 			goto SUB
@@ -127,6 +147,11 @@ func FileToParams(fpath string) map[string]string {
 	// file <---- filename <---- defaults <- subdefaults
 	params = mergeParams(params, params2, params3)
 
+	// Check params by nearest .schema (before any conversion)
+	if params2["Ext"] == _Md || finfo.IsDir() {
+		validateParams(params, pwd, diag)
+	}
+
 	// Slug modified if Redirect param is set
 	if params["Redirect"] != "" {
 		params["Slug"] = "-" + params["Slug"] // redirect suffix
@@ -143,6 +168,8 @@ func FileToParams(fpath string) map[string]string {
 			if dt, err := ToTime(params["VisibleFrom"]); err == nil {
 				params["VisibleFrom"] = fmt.Sprint(dt.UnixNano())
 				dtFrom = dt
+			} else {
+				diag.addParam(params["Path"], "VisibleFrom", SeverityError, "invalid VisibleFrom date %q", params["VisibleFrom"])
 			}
 		}
 		// To
@@ -150,6 +177,8 @@ func FileToParams(fpath string) map[string]string {
 			if dt, err := ToTime(params["VisibleTo"]); err == nil {
 				params["VisibleTo"] = fmt.Sprint(dt.UnixNano())
 				dtTo = dt
+			} else {
+				diag.addParam(params["Path"], "VisibleTo", SeverityError, "invalid VisibleTo date %q", params["VisibleTo"])
 			}
 		}
 
@@ -173,7 +202,8 @@ func FileToParams(fpath string) map[string]string {
 // Parse given bytes to map of params
 // strict - validate left side (keys) to be as variable
 // set strict=false when loading translations
-func bufToParams(buf []byte, strict bool) map[string]string {
+// Malformed lines are reported to diag as in file fpath (strict only)
+func bufToParams(buf []byte, strict bool, fpath string, diag *Diagnostics) map[string]string {
 	params := make(map[string]string, 0)
 
 	// Keep track of params, that are set in file by user
@@ -196,11 +226,16 @@ func bufToParams(buf []byte, strict bool) map[string]string {
 
 	// Parse keys: values
 	lines := bytes.Split(buf, nl)
-	for _, row := range lines {
-		row = bytes.TrimSpace(row)
+	lineNr := 0 // line in original buf
+	for i := 0; i < len(lines); i++ {
+		row := lines[i]
+		lineNr++
+		rowNr := lineNr
+		lineNr += bytes.Count(row, mlglue) // multiline rows takes more lines
+		rowIndent := indentOf(string(row))
 
-		// Skip not valid format "Key: Val"
-		if bytes.Index(row, sep) <= 0 {
+		row = bytes.TrimSpace(row)
+		if len(row) == 0 {
 			continue
 		}
 
@@ -208,7 +243,7 @@ func bufToParams(buf []byte, strict bool) map[string]string {
 		isComment := false ||
 			row[0] == "#"[0] || // #
 			row[0] == "/"[0] || // // or /*
-			row[0] == "-"[0] || // --
+			(row[0] == "-"[0] && !isRemoveParam(row)) || // -- (not -Key: val)
 			row[0] == "<"[0] || // <!--
 			row[0] == "\""[0] || // ""
 			row[0] == "~"[0] // ~
@@ -216,6 +251,19 @@ func bufToParams(buf []byte, strict bool) map[string]string {
 			continue
 		}
 
+		// Clear inherited value: !Key
+		if row[0] == '!' && bytes.Index(row, sep) < 0 {
+			row = []byte(string(row) + ":")
+		}
+
+		// Skip not valid format "Key: Val"
+		if bytes.Index(row, sep) <= 0 {
+			if strict {
+				diag.Add(fpath, rowNr, SeverityWarning, "malformed line %q (expected \"Key: Value\")", row)
+			}
+			continue
+		}
+
 		// Split to key and val
 		prop := bytes.SplitN(row, sep, 2)
 		key := bytes.TrimSpace(prop[0])
@@ -223,6 +271,7 @@ func bufToParams(buf []byte, strict bool) map[string]string {
 
 		// Key can't contain spaces
 		if strict && bytes.Index(key, []byte(" ")) > 0 {
+			diag.Add(fpath, rowNr, SeverityWarning, "param key %q can't contain spaces", key)
 			continue
 		}
 
@@ -232,6 +281,34 @@ func bufToParams(buf []byte, strict bool) map[string]string {
 			val = bytes.Replace(val, mlglue, nl, -1) // nl NOT ml
 		}
 
+		fp := &frontParams{params: params, fpath: fpath, diag: diag, lineNr: rowNr + 1}
+
+		// Nested params in indented block below
+		//	Social:
+		//	  Github: briiC
+		if len(val) == 0 && strict {
+			var block []string
+			for ; i+1 < len(lines); i++ {
+				next := string(lines[i+1])
+				if strings.TrimSpace(next) != "" && indentOf(next) <= rowIndent {
+					break
+				}
+				block = append(block, strings.Replace(next, string(mlglue), " ", -1))
+				lineNr++
+			}
+			if len(strings.TrimSpace(strings.Join(block, ""))) > 0 {
+				y := &yamlParser{frontParams: fp, lines: block}
+				y.parseValue(string(key), "", rowIndent)
+				continue
+			}
+		}
+
+		// Nested params as JSON value
+		//	Gallery: [{"Src": "a.jpg"}, {"Src": "b.jpg"}]
+		if jsonToParams(string(key), string(val), fp.set) {
+			continue
+		}
+
 		// Assign valid
 		params["SourceParams"] += string(key) + ", "
 		params[string(key)] = string(val)
@@ -240,15 +317,18 @@ func bufToParams(buf []byte, strict bool) map[string]string {
 	// Originaly set params inside file
 	// Not modified by mango yet
 
-	// Special Label and slug Treatment
-	// If no slug set, set slug using label
+	labelToSlug(params)
+	return params
+}
+
+// Special Label and slug Treatment
+// If no slug set, set slug using label
+func labelToSlug(params map[string]string) {
 	if pLabel, isLabel := params["Label"]; isLabel {
 		if _, isSlug := params["Slug"]; !isSlug {
 			params["Slug"] = toSlug(pLabel)
 		}
 	}
-
-	return params
 }
 
 // Parse string (filename) to params
@@ -331,3 +411,27 @@ func toSlug(s string) string {
 	}
 	return slug
 }
+
+// Copy file creating all parent directories
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -83,7 +83,7 @@ func Test_NewApplication(t *testing.T) {
 
 	// By default must be 3 collections:
 	// Tags, Categories, Keywords
-	if len(app.collections) != 3 {
+	if len(app.collectionKeys) != 3 {
 		t.Fatal("Must be 3 default collections")
 	}
 
@@ -103,17 +103,12 @@ func Test_AppPageFuncs(t *testing.T) {
 		// Set this func, but do nothing
 		// Test coverage purpose
 	}
-	ioutil.WriteFile(app.BinPath()+"/.reload", nil, 0666)
 
 	// Search
-	pages := app.Search("en", "oc") // hOCkey, sOCcer, http://remote.lOC/.., localhost..
-	if len(pages) != 4 && len(pages) != 3 {
-		// NOTE: There can be different count of results depending
-		// where test are executed
-		// Because of one result tries to get content from "http://localhost"
-		// And if it fails that result not counted (one result less)
+	pages := app.Search("en", "cats") // About cats, Cat (in Label)
+	if len(pages) != 2 || pages[0].Get("Slug") != "about-cats" {
 		pages.Print()
-		t.Fatal("Must be found 4 pages")
+		t.Fatal("Must be found 2 pages")
 	}
 
 	// Search - no such slug, no results
@@ -122,9 +117,6 @@ func Test_AppPageFuncs(t *testing.T) {
 		t.Fatal("Must be found 0 pages")
 	}
 
-	// Check reload file
-	ioutil.WriteFile(app.binPath+"/.reload", []byte("..."), 0644)
-
 	// new virtual page
 	// linked to app, but no parents
 	// not listed anywhere
@@ -0,0 +1,111 @@
+package mango
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Create site in temp dir with given asset mode
+func tAssetSite(t *testing.T, mode string) (string, *Application) {
+	return tSite(t, map[string]string{
+		"/.mango":                   "Assets: " + mode + "\n",
+		"/content/en/logo.png":      "en logo",
+		"/content/en/file.pdf":      "pdf",
+		"/content/en/news/logo.png": "news logo",
+		"/content/en/news/Post.md": "Title: Post\n+++\n" +
+			"![a](logo.png)\n![b](../logo.png)\n![c](/en/logo.png)\n" +
+			"![d](images/file.pdf)\n[e](data/file.pdf)\n![f](http://remote.loc/logo.png)\n",
+	})
+}
+
+func Test_AssetsKeep(t *testing.T) {
+	dir, app := tAssetSite(t, "Keep")
+
+	// Nothing moved
+	for _, fpath := range []string{"/content/en/logo.png", "/content/en/news/logo.png", "/content/en/file.pdf"} {
+		if _, err := os.Stat(dir + fpath); err != nil {
+			t.Fatal("Asset must stay in content", fpath)
+		}
+	}
+
+	// Links by content-relative path
+	s := string(app.Page("post").Content())
+	for _, expected := range []string{
+		`src="/assets/en/news/logo.png" alt="a"`,
+		`src="/assets/en/logo.png" alt="b"`,
+		`src="/assets/en/logo.png" alt="c"`,
+		`src="/assets/en/file.pdf" alt="d"`,
+		`href="/assets/en/file.pdf">e`,
+		`src="http://remote.loc/logo.png" alt="f"`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Fatalf("Content must contain [%s]. Found: [%s]", expected, s)
+		}
+	}
+
+	// Served from content
+	srv := NewAppServer(app, 0)
+	for url, expected := range map[string]string{
+		"/assets/en/news/logo.png": "news logo",
+		"/assets/en/logo.png":      "en logo",
+	} {
+		w := httptest.NewRecorder()
+		srv.fileServer().ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Body.String() != expected {
+			t.Fatalf("[%s] must serve [%s]. Found: [%s]", url, expected, w.Body.String())
+		}
+	}
+
+	// Only assets, not pages
+	w := httptest.NewRecorder()
+	srv.fileServer().ServeHTTP(w, httptest.NewRequest("GET", "/assets/en/news/Post.md", nil))
+	if w.Code != 404 {
+		t.Fatal("Page files must not be served", w.Code)
+	}
+
+	// New asset is linked after reload
+	ioutil.WriteFile(dir+"/content/en/news/new.png", []byte("new"), 0644)
+	ioutil.WriteFile(dir+"/content/en/news/Post.md", []byte("![new](new.png)"), 0644)
+	app.ReloadPath(dir+"/content/en/news/new.png", dir+"/content/en/news/Post.md")
+	if s := string(app.Page("post").Content()); !strings.Contains(s, `src="/assets/en/news/new.png"`) {
+		t.Fatal("New asset must be linked", s)
+	}
+}
+
+func Test_AssetsCopy(t *testing.T) {
+	dir, app := tAssetSite(t, "Copy")
+
+	for _, rpath := range []string{"en/logo.png", "en/news/logo.png", "en/file.pdf"} {
+		if _, err := os.Stat(dir + "/content/" + rpath); err != nil {
+			t.Fatal("Asset must stay in content", rpath)
+		}
+		if _, err := os.Stat(app.PublicPath + "/assets/" + rpath); err != nil {
+			t.Fatal("Asset must be copied to public path", rpath)
+		}
+	}
+
+	if s := string(app.Page("post").Content()); !strings.Contains(s, `src="/assets/en/news/logo.png" alt="a"`) {
+		t.Fatal("Assets must be linked by content-relative path", s)
+	}
+}
+
+func Test_AssetsMoveCollision(t *testing.T) {
+	dir, app := tAssetSite(t, "Move")
+
+	if _, err := os.Stat(dir + "/content/en/logo.png"); !os.IsNotExist(err) {
+		t.Fatal("Asset must be moved")
+	}
+
+	isFound := false
+	for _, d := range app.Diagnostics() {
+		if strings.Contains(d.String(), "warning: asset \"logo.png\" overwrites") {
+			isFound = true
+		}
+	}
+	if !isFound {
+		t.Fatal("Same asset names must be reported", app.Diagnostics())
+	}
+}
@@ -13,7 +13,7 @@ func Test_Counts(t *testing.T) {
 	}
 
 	// Collections
-	if count := len(app.collections); count != 3 {
+	if count := len(app.Snapshot().collections); count != 3 {
 		app.Print()
 		t.Fatal("Collection count incorrect. Found:", count)
 	}
@@ -23,25 +23,25 @@ func Test_TagsCounts(t *testing.T) {
 	app, _ := NewApplication()
 
 	// Collections: Tags
-	if count := app.collections["Tags"].Len(); count != 3 {
+	if count := app.Snapshot().collections["Tags"].Len(); count != 3 {
 		app.Print()
 		t.Fatal("[Tags] count incorrect. Found:", count)
 	}
 
 	// Collections: Tags: animal
-	if count := len(app.collections["Tags"].Get("animal")); count != 5 {
+	if count := len(app.Snapshot().collections["Tags"].Get("animal")); count != 5 {
 		app.Print()
 		t.Fatal("[Tags: animal] count incorrect. Found:", count)
 	}
 
 	// Collections: Tags: pet
-	if count := len(app.collections["Tags"].Get("pet")); count != 2 {
+	if count := len(app.Snapshot().collections["Tags"].Get("pet")); count != 2 {
 		app.Print()
 		t.Fatal("[Tags: pet] count incorrect. Found:", count)
 	}
 
 	// Collections: Tags: nice
-	if count := len(app.collections["Tags"].Get("nice")); count != 1 {
+	if count := len(app.Snapshot().collections["Tags"].Get("nice")); count != 1 {
 		app.Print()
 		t.Fatal("[Tags: nice] count incorrect. Found:", count)
 	}
@@ -51,7 +51,7 @@ func Test_CategoriesCounts(t *testing.T) {
 	app, _ := NewApplication()
 
 	// Collections: Categories
-	if count := app.collections["Categories"].Len(); count != 4 {
+	if count := app.Snapshot().collections["Categories"].Len(); count != 4 {
 		app.Print()
 		t.Fatal("[Categories] count incorrect. Found:", count)
 	}
@@ -62,7 +62,7 @@ func Test_KeywordsCounts(t *testing.T) {
 	app, _ := NewApplication()
 
 	// Collections: Keywords
-	if count := app.collections["Keywords"].Len(); count != 7 {
+	if count := app.Snapshot().collections["Keywords"].Len(); count != 7 {
 		app.Print()
 		t.Fatal("[Keywords] count incorrect. Found:", count)
 	}
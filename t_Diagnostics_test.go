@@ -0,0 +1,121 @@
+package mango
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Diagnostics(t *testing.T) {
+	app, _ := NewApplication()
+
+	// Define problems in test-files and where they are found
+	problems := []string{
+		"1_Hello.md:3: error: invalid VisibleTo date",
+		"1_Hello.md:4: warning: param key \"Spaced key\"",
+		"6_Hello.md: warning: duplicate slug \"hello\"",
+		"8_Remote.md:1: error: can't fetch ContentFrom",
+	}
+
+	list := app.Diagnostics()
+	for _, problem := range problems {
+		isFound := false
+		for _, d := range list {
+			if strings.Contains(d.String(), problem) {
+				isFound = true
+				break
+			}
+		}
+		if !isFound {
+			t.Fatalf("Problem [%s] not found in %v", problem, list)
+		}
+	}
+
+	// Nil collector ignores everything
+	var ds *Diagnostics
+	ds.Add("/x.md", 1, SeverityError, "nothing")
+	if ds.Len() != 0 || ds.Err() != nil {
+		t.Fatal("Nil diagnostics must be empty")
+	}
+}
+
+func Test_DiagnosticsReload(t *testing.T) {
+	app, _ := NewApplication()
+	count := len(app.Diagnostics())
+
+	fpath := app.ContentPath + "/1_en/top-menu/2_News/Broken.md"
+	defer os.Remove(fpath)
+
+	ioutil.WriteFile(fpath, []byte("Title: Broken\nno colon here\nVisibleFrom: tomorrow\n+++\nBroken"), 0644)
+	app.ReloadPath(fpath)
+
+	ds := app.Snapshot().diagnostics
+	if len(ds.List()) != count+2 {
+		t.Fatal("Reloaded page problems must be added", ds.List())
+	}
+	for _, d := range ds.Errors() {
+		if d.Path == fpath && d.Line != 3 {
+			t.Fatal("Incorrect problem position", d)
+		}
+	}
+
+	// Fixed file
+	ioutil.WriteFile(fpath, []byte("Title: Broken\nVisibleFrom: 2019-01-01\n+++\nFixed"), 0644)
+	app.ReloadPath(fpath)
+	if len(app.Diagnostics()) != count {
+		t.Fatal("Fixed page problems must be removed", app.Diagnostics())
+	}
+}
+
+func Test_DiagnosticsStrict(t *testing.T) {
+	dir := tWriteSite(t, map[string]string{
+		"/content/en/Page.md":  "VisibleFrom: someday\n+++\nPage",
+		"/content/en/Other.md": "ContentFrom: no-such-page\n+++\n",
+	})
+
+	// Not strict
+	cwd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(cwd)
+
+	ioutil.WriteFile(dir+"/.mango", []byte("Domain: example.loc\n"), 0644)
+	app, err := NewApplicationAt(dir)
+	if err != nil {
+		t.Fatal("Content errors must not fail without strict mode", err)
+	}
+	if len(app.Snapshot().diagnostics.Errors()) != 2 {
+		t.Fatal("Must be 2 errors", app.Diagnostics())
+	}
+
+	// Strict
+	ioutil.WriteFile(dir+"/.mango", []byte("Domain: example.loc\nStrict: Yes\n"), 0644)
+	app, err = NewApplicationAt(dir)
+	if err == nil || app == nil {
+		t.Fatal("Content errors must fail in strict mode")
+	}
+	if !strings.HasPrefix(err.Error(), "2 content error(s):") || !strings.Contains(err.Error(), "Page.md:1: error: invalid VisibleFrom") {
+		t.Fatal("Incorrect error", err)
+	}
+}
+
+func Test_DiagnosticsRemoteStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Error page", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	_, app := tSite(t, map[string]string{
+		"/content/en/news/Remote.md": "ContentFrom: " + ts.URL + "\n+++\nOwn content",
+	})
+
+	if s := string(app.Page("remote").Content()); !strings.Contains(s, "Own content") || strings.Contains(s, "Error page") {
+		t.Fatal("Error page must not be content", s)
+	}
+	if list := app.Diagnostics(); len(list) != 1 || !strings.Contains(list[0].String(), "Remote.md:1: error: can't fetch ContentFrom") ||
+		!strings.Contains(list[0].String(), "responded 404 Not Found") {
+		t.Fatal("Error status must be reported", list)
+	}
+}
@@ -0,0 +1,114 @@
+package mango
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Export(t *testing.T) {
+	srv := NewServer(3000)
+
+	dir, _ := ioutil.TempDir("", "mango-export")
+	defer os.RemoveAll(dir)
+
+	if err := srv.Export(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Define files and expected content
+	files := map[string]string{
+		"/index.html":                    "</h1>\nindex",
+		"/en/index.html":                 "</h1>\nindex",
+		"/lv/index.html":                 "</h1>\nindex",
+		"/en/hello.html":                 "</h1>\none",
+		"/en/news.html":                  "</h1>\ngroup",
+		"/en/about-cats.html":            "</h1>\none",
+		"/en/-go-to-lv.html":             "url=/lv",
+		"/404.html":                      "</h1>\n404",
+		"/sitemap.xml":                   "<urlset",
+		"/en/en-top-menu.html":           "", // top level pages not exported
+		"/en/.en-defaults.html":          "",
+		"/en/my-secret-post.html":        "</h1>\none", // unlisted, but accessible
+		"/en/no-such-page/index.html":    "",
+		"/en/hello-2.html":               "</h1>\none",
+		"/en/look-at-these-animals.html": "</h1>\none",
+	}
+
+	for fpath, expected := range files {
+		buf, err := ioutil.ReadFile(dir + fpath)
+		if expected == "" {
+			if err == nil {
+				t.Fatalf("[%s] must not be exported", fpath)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("[%s] must be exported", fpath)
+		}
+		if !strings.Contains(string(buf), expected) {
+			t.Fatalf("[%s] must contain [%s] but found [%s]", fpath, expected, buf)
+		}
+	}
+
+	// URL to file path
+	cases := map[string]string{
+		"/en/hello.html": "/x/en/hello.html",
+		"/en/hello":      "/x/en/hello/index.html",
+		"/en/":           "/x/en/index.html",
+		"/":              "/x/index.html",
+	}
+	for url, expected := range cases {
+		if fpath := urlToFilePath("/x", url); fpath != expected {
+			t.Fatalf("[%s] must be exported to [%s] not [%s]", url, expected, fpath)
+		}
+	}
+}
+
+func Test_ExportDryRun(t *testing.T) {
+	dir := tWriteSite(t, map[string]string{
+		"/.mango":                  "Domain: https://example.loc\n",
+		"/templates/layout.tmpl":   `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/public/css/style.css":    "body{}",
+		"/content/en/logo.png":     "png",
+		"/content/en/site/Post.md": "![logo](logo.png)",
+	})
+
+	app, err := OpenApplication(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.LoadContentDryRun()
+
+	out := dir + "/out"
+	if err := NewAppServer(app, 0).Export(out); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing changed in site
+	for _, fpath := range []string{"/public/sitemap.xml", "/public/feeds", "/public/highlight.css", "/public/images/logo.png"} {
+		if _, err := os.Stat(dir + fpath); err == nil {
+			t.Fatal("Dry run must not write", fpath)
+		}
+	}
+	if _, err := os.Stat(dir + "/content/en/logo.png"); err != nil {
+		t.Fatal("Dry run must not move assets")
+	}
+
+	// Everything is in export
+	files := map[string]string{
+		"/en/post/index.html": `src="/images/logo.png"`,
+		"/images/logo.png":    "png",
+		"/css/style.css":      "body{}",
+		"/sitemap.xml":        "<loc>https://example.loc/en/post</loc>",
+		"/feeds/en/rss.xml":   "<link>https://example.loc/en/post</link>",
+		"/highlight.css":      ".chroma",
+	}
+	for fpath, expected := range files {
+		if buf, _ := ioutil.ReadFile(out + fpath); !strings.Contains(string(buf), expected) {
+			t.Fatalf("[%s] must contain [%s] but found [%s]", fpath, expected, buf)
+		}
+	}
+}
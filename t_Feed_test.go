@@ -0,0 +1,110 @@
+package mango
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Feeds(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/.mango":                        "Domain: https://example.loc\nCollections: Tags\nFeedSize: 2\n",
+		"/templates/layout.tmpl":         `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/content/en/site/blog/.dir":     "IsFeed: Yes\nFeedSize: 5",
+		"/content/en/site/blog/Old.md":   "Date: 2020-01-01\nTags: dog\n+++\nOld & gold",
+		"/content/en/site/blog/New.md":   "Date: 2024-05-01\nTags: dog, House pets\n+++\nNew 1 < 2",
+		"/content/en/site/blog/Mid.md":   "Date: 2022-03-01\n+++\nMid",
+		"/content/en/site/blog/Moved.md": "Redirect: /en/new\n+++\n",
+		"/content/en/site/docs/Doc.md":   "Date: 2023-01-01\n+++\nDoc",
+		"/content/lv/site/Raksts.md":     "Date: 2021-01-01\nTags: dog\n+++\nSuns",
+		"/public/feeds/logo.png":         "logo",
+		"/public/feeds/custom/notes.txt": "notes",
+		"/public/feeds/gone/rss.xml":     "old feed",
+	})
+
+	// Written on load
+	expected := []string{"en", "en/tags/dog", "en/tags/house-pets", "blog", "lv", "lv/tags/dog"}
+	for _, path := range expected {
+		for fname := range feedTypes {
+			if _, err := os.Stat(app.PublicPath + "/feeds/" + path + "/" + fname); err != nil {
+				t.Fatal("Feed must be written", err)
+			}
+		}
+	}
+	if _, err := os.Stat(app.PublicPath + "/feeds/docs"); err == nil {
+		t.Fatal("Feed only for directories with IsFeed")
+	}
+
+	// Only feed files are replaced
+	if _, err := os.Stat(app.PublicPath + "/feeds/gone"); err == nil {
+		t.Fatal("Old feeds must be removed")
+	}
+	for _, fname := range []string{"logo.png", "custom/notes.txt"} {
+		if _, err := os.Stat(app.PublicPath + "/feeds/" + fname); err != nil {
+			t.Fatal("Other files in feeds directory must stay", err)
+		}
+	}
+
+	srv := NewAppServer(app, 0)
+	ts := httptest.NewServer(srv.preStart())
+	defer ts.Close()
+
+	// RSS of directory (newest first, no redirects)
+	resp, body := tHTTPGet(t, ts.URL+"/feeds/blog/rss.xml")
+	if ct := resp.Header.Get("Content-Type"); ct != "application/rss+xml; charset=utf-8" {
+		t.Fatal("Wrong content type", ct)
+	}
+	parts := []string{
+		`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`,
+		`<atom:link href="https://example.loc/feeds/blog/rss.xml" rel="self" type="application/rss+xml"></atom:link>`,
+		"<title>New</title>\n      <link>https://example.loc/en/new</link>",
+		`<pubDate>Wed, 01 May 2024 00:00:00 +0000</pubDate>`,
+		`<description>New 1 &lt; 2</description>`,
+	}
+	for _, part := range parts {
+		if !strings.Contains(body, part) {
+			t.Fatalf("RSS must contain %s\n%s", part, body)
+		}
+	}
+	if strings.Index(body, "<title>New</title>") > strings.Index(body, "<title>Mid</title>") ||
+		strings.Contains(body, "Moved") || strings.Contains(body, "Doc") {
+		t.Fatal("RSS must have newest pages first and no redirects", body)
+	}
+
+	// Atom of language root (FeedSize from config)
+	resp, body = tHTTPGet(t, ts.URL+"/feeds/en/atom.xml")
+	if ct := resp.Header.Get("Content-Type"); ct != "application/atom+xml; charset=utf-8" {
+		t.Fatal("Wrong content type", ct)
+	}
+	if !strings.Contains(body, `<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">`) ||
+		!strings.Contains(body, `<updated>2024-05-01T00:00:00Z</updated>`) ||
+		strings.Count(body, "<entry>") != 2 || strings.Contains(body, "Suns") {
+		t.Fatal("Atom must have 2 newest pages of language", body)
+	}
+
+	// JSON Feed of collection item
+	resp, body = tHTTPGet(t, ts.URL+"/feeds/en/tags/dog/feed.json")
+	if ct := resp.Header.Get("Content-Type"); ct != "application/feed+json; charset=utf-8" {
+		t.Fatal("Wrong content type", ct)
+	}
+	var jf jsonFeed
+	if err := json.Unmarshal([]byte(body), &jf); err != nil {
+		t.Fatal(err, body)
+	}
+	if jf.Title != "Tags: dog" || len(jf.Items) != 2 || jf.Items[0].URL != "https://example.loc/en/new" || jf.Items[1].ContentText != "Old & gold" {
+		t.Fatal("JSON feed must have pages of collection item in language", body)
+	}
+
+	// Public files under /feeds/
+	if resp, body := tHTTPGet(t, ts.URL+"/feeds/logo.png"); resp.StatusCode != 200 || body != "logo" {
+		t.Fatal("Public file in feeds directory must be served", resp.StatusCode, body)
+	}
+
+	for _, url := range []string{"/feeds/docs/rss.xml", "/feeds/blog/feed.txt", "/feeds/en/tags/cat/rss.xml"} {
+		if resp, _ := tHTTPGet(t, ts.URL+url); resp.StatusCode != 404 {
+			t.Fatal("Not found expected", url, resp.StatusCode)
+		}
+	}
+}
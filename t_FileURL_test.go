@@ -0,0 +1,138 @@
+package mango
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Create site in temp dir with given FileURL template
+func tFileURLSite(t *testing.T, fileURL string) (string, *Application) {
+	return tSite(t, map[string]string{
+		"/.mango":                 "FileURL: " + fileURL + "\n",
+		"/templates/layout.tmpl":  `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/public/css/style.css":   "body{}",
+		"/content/en/file.pdf":    "pdf",
+		"/content/en/news/Doc.md": "[pdf](file.pdf)",
+	})
+}
+
+func Test_FileHash(t *testing.T) {
+	dir, app := tFileURLSite(t, "/static/{Hash}/{File}")
+
+	sum := sha256.Sum256([]byte("body{}"))
+	hash := hex.EncodeToString(sum[:])[:12]
+
+	if h := app.FileHash("css/style.css"); h != hash {
+		t.Fatal("Incorrect hash", h)
+	}
+	if h := app.FileHash("no/such.css"); h != "" {
+		t.Fatal("Missing file must not have hash", h)
+	}
+
+	// Urls
+	if url := app.FileURL("css/style.css"); url != "/static/"+hash+"/css/style.css" {
+		t.Fatal("Incorrect file url", url)
+	}
+	if url := tFileURL(app.Page("doc"), "css", "style.css"); url != "/static/"+hash+"/css/style.css" {
+		t.Fatal("Incorrect template file url", url)
+	}
+	if url := app.FileURL("no/such.css"); url != "/static/0/no/such.css" {
+		t.Fatal("Incorrect missing file url", url)
+	}
+	pdfHash := app.FileHash("data/file.pdf")
+	if s := string(app.Page("doc").Content()); !strings.Contains(s, `href="/static/`+pdfHash+`/data/file.pdf"`) {
+		t.Fatal("Content must have fingerprinted urls", s)
+	}
+
+	// New content - new hash
+	ioutil.WriteFile(dir+"/public/css/style.css", []byte("body{color:red}"), 0644)
+	if h := app.FileHash("css/style.css"); h == hash || len(h) != 12 {
+		t.Fatal("Hash must change with content", h)
+	}
+}
+
+func Test_FileHashReload(t *testing.T) {
+	dir, app := tFileURLSite(t, "/static/{Hash}/{File}")
+	w := &Watcher{app: app}
+
+	pdfHash := app.FileHash("data/file.pdf")
+	fpath := dir + "/public/data/file.pdf"
+	if !w.isRelevant(fpath, fsnotify.Write) {
+		t.Fatal("Public file must be watched with {Hash} in FileURL")
+	}
+	if w.isRelevant(dir+"/public/sitemap.xml", fsnotify.Write) {
+		t.Fatal("Generated files must not be watched")
+	}
+
+	// Public file changed - page gets new hash
+	ioutil.WriteFile(fpath, []byte("new pdf"), 0644)
+	app.ReloadPath(fpath)
+	newHash := app.FileHash("data/file.pdf")
+	if newHash == pdfHash {
+		t.Fatal("Hash must change with content", newHash)
+	}
+	if s := string(app.Page("doc").Content()); !strings.Contains(s, `href="/static/`+newHash+`/data/file.pdf"`) {
+		t.Fatal("Content must have new fingerprinted url", s)
+	}
+
+	// Without {Hash} public files are not watched
+	_, app = tFileURLSite(t, "/{File}")
+	w = &Watcher{app: app}
+	if w.isRelevant(app.PublicPath+"/css/style.css", fsnotify.Write) {
+		t.Fatal("Public files must not be watched without {Hash}")
+	}
+}
+
+func Test_FileHashServe(t *testing.T) {
+	for _, fileURL := range []string{"/static/{Hash}/{File}", "/{File}?v={Hash}"} {
+		dir, app := tFileURLSite(t, fileURL)
+
+		srv := NewAppServer(app, 0)
+		ts := httptest.NewServer(srv.preStart())
+		defer ts.Close()
+
+		// Current hash - cached forever
+		url := app.FileURL("css/style.css")
+		res, body := tHTTPGet(t, ts.URL+url)
+		if res.StatusCode != 200 || body != "body{}" {
+			t.Fatal(fileURL, "File must be served", url, res.StatusCode, body)
+		}
+		if cc := res.Header.Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+			t.Fatal(fileURL, "Incorrect Cache-Control", cc)
+		}
+
+		// Old hash - still served, but not cached
+		url = strings.Replace(url, app.FileHash("css/style.css"), "123456789abc", 1)
+		res, body = tHTTPGet(t, ts.URL+url)
+		if res.StatusCode != 200 || body != "body{}" || res.Header.Get("Cache-Control") != "" {
+			t.Fatal(fileURL, "Old url must be served without caching", url, res.StatusCode, res.Header)
+		}
+
+		// Export to fingerprinted paths
+		out := dir + "/out"
+		if err := srv.Export(out); err != nil {
+			t.Fatal(err)
+		}
+		if buf, _ := ioutil.ReadFile(fileURLToPath(out, app.FileURL("css/style.css"))); string(buf) != "body{}" {
+			t.Fatal(fileURL, "File must be exported by its url")
+		}
+	}
+}
+
+// GET url and read body
+func tHTTPGet(t *testing.T, url string) (*http.Response, string) {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(url, err)
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	return res, string(body)
+}
@@ -0,0 +1,186 @@
+package mango
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// Write file in temp dir and read its params
+func tFrontMatterParams(t *testing.T, fname, content string) map[string]string {
+	dir := tWriteSite(t, map[string]string{"/" + fname: content})
+	return FileToParams(dir + "/" + fname)
+}
+
+func Test_FrontMatterYAML(t *testing.T) {
+	params := tFrontMatterParams(t, "Post.md", `---
+title: "Hello: world" # comment
+slug: hello
+draft: false
+tags: [dog, "cat, mouse"]
+categories:
+  - House pets
+  - Farm
+author:
+  name: John
+  links:
+    site: https://example.com
+gallery:
+  - src: a.jpg
+    alt: First
+  - src: b.jpg
+description: |
+  First line
+  Second line
+summary: >
+  Folded
+  text
+---
+# Content
+Text with --- inside`)
+
+	cases := map[string]string{
+		"Title":             "Hello: world",
+		"Slug":              "hello",
+		"Draft":             "No",
+		"Tags":              "dog, cat, mouse",
+		"Categories":        "House pets, Farm",
+		"Author.Name":       "John",
+		"Author.Links.Site": "https://example.com",
+		"Gallery.0.Src":     "a.jpg",
+		"Gallery.0.Alt":     "First",
+		"Gallery.1.Src":     "b.jpg",
+		"Description":       "First line\nSecond line",
+		"Summary":           "Folded text",
+		"Content":           "# Content\nText with --- inside",
+		"HaveContent":       "Yes",
+	}
+	for key, val := range cases {
+		if params[key] != val {
+			printMap("yaml", params)
+			t.Fatalf("%q expected to be %q (found %q)", key, val, params[key])
+		}
+	}
+	if !strings.Contains(params["SourceParams"], "Gallery.1.Src, ") {
+		t.Fatal("Flattened keys must be source params", params["SourceParams"])
+	}
+}
+
+func Test_FrontMatterTOML(t *testing.T) {
+	params := tFrontMatterParams(t, "Post.md", `+++
+title = "Hello"
+label = 'It''s me'
+weight = 10
+draft = true
+tags = [
+  "dog",
+  "cat", # comment
+]
+author.name = "John"
+notes = """
+One
+Two"""
+
+[params]
+color = "red"
+
+[[gallery]]
+src = "a.jpg"
+
+[[gallery]]
+src = "b.jpg"
++++
+Content`)
+
+	cases := map[string]string{
+		"Title":         "Hello",
+		"Label":         "It's me",
+		"Slug":          "it-s-me",
+		"Weight":        "10",
+		"Draft":         "Yes",
+		"Tags":          "dog, cat",
+		"Author.Name":   "John",
+		"Notes":         "One\nTwo",
+		"Params.Color":  "red",
+		"Gallery.0.Src": "a.jpg",
+		"Gallery.1.Src": "b.jpg",
+		"Content":       "Content",
+	}
+	for key, val := range cases {
+		if params[key] != val {
+			printMap("toml", params)
+			t.Fatalf("%q expected to be %q (found %q)", key, val, params[key])
+		}
+	}
+}
+
+func Test_FrontMatterDefault(t *testing.T) {
+	// Mango header stays default
+	params := tFrontMatterParams(t, "Post.md", "Title: Hello\nTags: dog, cat\n+++\nContent")
+	if params["Title"] != "Hello" || params["Tags"] != "dog, cat" || params["Content"] != "Content" {
+		t.Fatal("Mango header must be parsed", params)
+	}
+
+	// Not closed front matter is content
+	params = tFrontMatterParams(t, "Open.md", "---\ntitle: Hello\n\nText")
+	if params["Title"] != "Open" || !strings.HasPrefix(params["Content"], "---") {
+		t.Fatal("Not closed front matter must be content", params)
+	}
+
+	// Horizontal rules, not front matter
+	params = tFrontMatterParams(t, "Rule.md", "---\nJust a paragraph, with words\n---\nMore text")
+	if params["Title"] != "Rule" || params["Content"] != "---\nJust a paragraph, with words\n---\nMore text" {
+		t.Fatal("Page opening with --- must keep content", params)
+	}
+	params = tFrontMatterParams(t, "Note.md", "---\nPlease note: text\n---\nText")
+	if params["Title"] != "Note" || !strings.HasPrefix(params["Content"], "---\nPlease note: text") {
+		t.Fatal("Sentence with colon must not be front matter", params)
+	}
+
+	// Problems with line numbers
+	diag := NewDiagnostics()
+	dir := tWriteSite(t, map[string]string{"/Bad.md": "---\ntitle: Bad\nno colon here\n---\nBad"})
+	fileToParams(dir+"/Bad.md", diag)
+	if list := diag.List(); len(list) != 1 || list[0].Line != 3 {
+		t.Fatal("Malformed line must be reported", list)
+	}
+}
+
+func Test_FrontMatterDates(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/content/en/news/Yaml.md":   "---\ndate: 2024-01-02T10:00:00+02:00\n---\nYAML",
+		"/content/en/news/Toml.md":   "+++\ndate = 2024-01-02T08:00:00Z\n+++\nTOML",
+		"/content/en/news/Jekyll.md": "---\ndate: 2024-01-02 10:00:00 +0200\n---\nJekyll",
+	})
+
+	expected := time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)
+	for _, slug := range []string{"yaml", "toml", "jekyll"} {
+		if dt := app.Page(slug).GetTime("Date"); !dt.Equal(expected) {
+			t.Fatal(slug, "date must be parsed", dt, app.Page(slug).Get("Date"))
+		}
+	}
+}
+
+func Test_FrontMatterEmptyKeys(t *testing.T) {
+	dir := tWriteSite(t, map[string]string{
+		"/Yaml.md": "---\ntitle: Yaml\n\"\": x\n---\nYaml",
+		"/Toml.md": "+++\ntitle = \"Toml\"\na..b = 1\nc = { \"\" = 2 }\n+++\nToml",
+	})
+
+	for fname, problems := range map[string]int{"Yaml.md": 1, "Toml.md": 2} {
+		diag := NewDiagnostics()
+		params := fileToParams(dir+"/"+fname, diag)
+		for key := range params {
+			if strings.ContainsRune(key, utf8.RuneError) || strings.HasPrefix(key, ".") || strings.Contains(key, "..") {
+				t.Fatal("Key with empty part must be skipped", fname, key)
+			}
+		}
+		if params["Title"] == "" {
+			t.Fatal("Other keys must be parsed", fname, params)
+		}
+		if list := diag.List(); len(list) != problems {
+			t.Fatal("Empty key parts must be reported", fname, list)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package mango
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_HTTPCache(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/.mango":                      "CacheControl: public, max-age=60\n",
+		"/templates/layout.tmpl":       `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/content/en/news/About.md":    "About us",
+		"/content/en/news/News.md":     "CacheControl: no-cache\n+++\nLatest news",
+		"/content/en/news/Redirect.md": "Redirect: /en/about\n+++\nMoved",
+	})
+	srv := NewAppServer(app, 0)
+	ts := httptest.NewServer(srv.preStart())
+	defer ts.Close()
+
+	url := ts.URL + app.Page("about").Get("URL")
+	res, body := tHTTPGet(t, url)
+	etag := res.Header.Get("ETag")
+	if res.StatusCode != 200 || !strings.Contains(body, "About us") {
+		t.Fatal("Page must be served", res.StatusCode, body)
+	}
+	if etag == "" || res.Header.Get("Last-Modified") == "" {
+		t.Fatal("ETag and Last-Modified must be set", res.Header)
+	}
+	if cc := res.Header.Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Fatal("Site-wide Cache-Control expected", cc)
+	}
+
+	// Conditional requests
+	for key, value := range map[string]string{
+		"If-None-Match":     etag,
+		"If-Modified-Since": res.Header.Get("Last-Modified"),
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set(key, value)
+		res2, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res2.Body.Close()
+		if res2.StatusCode != http.StatusNotModified {
+			t.Fatal(key, "must return 304", res2.StatusCode)
+		}
+	}
+
+	// Changed page - new ETag
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("If-None-Match", `"changed"`)
+	res, _ = http.DefaultClient.Do(req)
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Fatal("Other ETag must get full page", res.StatusCode)
+	}
+
+	// Page overrides default
+	res, _ = tHTTPGet(t, ts.URL+app.Page("news").Get("URL"))
+	if cc := res.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Fatal("Page Cache-Control expected", cc)
+	}
+	if res.Header.Get("ETag") == etag {
+		t.Fatal("Different pages must have different ETag")
+	}
+
+	// Redirect is not rendered
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, _ = client.Get(ts.URL + "/en/-redirect")
+	res.Body.Close()
+	if res.StatusCode != http.StatusTemporaryRedirect || res.Header.Get("ETag") != "" {
+		t.Fatal("Redirect must not render page", res.StatusCode, res.Header)
+	}
+}
@@ -2,10 +2,45 @@ package mango
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
 
+// Write site files to temp directory (removed when test ends)
+// files["/content/en/Post.md"] = "Title: Post\n+++\nText"
+func tWriteSite(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "mango-site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for fpath, content := range files {
+		os.MkdirAll(filepath.Dir(dir+fpath), 0755)
+		if err := ioutil.WriteFile(dir+fpath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// Application loaded from site files in temp directory
+func tSite(t *testing.T, files map[string]string) (string, *Application) {
+	t.Helper()
+
+	dir := tWriteSite(t, files)
+	app, err := NewApplicationAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, app
+}
+
 // Parsing datetimes
 func Test_ToTime(t *testing.T) {
 	dtNow := time.Now() // to check current values
@@ -45,4 +80,21 @@ func Test_ToTime(t *testing.T) {
 		}
 	}
 
+	// RFC3339 (Hugo, Jekyll)
+	zoned := map[string]string{
+		"2024-01-02T10:00:00Z":        "2024-01-02T10:00:00Z",
+		"2024-01-02T10:00:00+02:00":   "2024-01-02T08:00:00Z",
+		"2024-01-02T10:00:00.5-03:00": "2024-01-02T13:00:00.5Z",
+		"2024-01-02 10:00:00+02:00":   "2024-01-02T08:00:00Z",
+		"2024-01-02 10:00:00 +0200":   "2024-01-02T08:00:00Z",
+		"2024-01-02T10:00:00":         "2024-01-02T10:00:00Z",
+		"2024-01-02 10:00:00.123456Z": "2024-01-02T10:00:00.123456Z",
+	}
+	for in, expected := range zoned {
+		dt, err := ToTime(in)
+		if err != nil || dt.UTC().Format(time.RFC3339Nano) != expected {
+			t.Fatal(in, "EXPECTED:", expected, "GOT:", dt, err)
+		}
+	}
+
 }
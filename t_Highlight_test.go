@@ -0,0 +1,79 @@
+package mango
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Highlight(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/.mango":                "HighlightStyle: monokai\n",
+		"/templates/layout.tmpl": `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/content/en/news/Code.md": "# Code\n\n" +
+			"```go {linenos=true, hl_lines=\"2\", linenostart=10}\npackage main\nfunc main() {}\n```\n\n" +
+			"```\nplain <text>\n```\n\n" +
+			"```nosuchlang\nother\n```\n",
+		"/content/en/news/Lines.md": "MarkdownLineNumbers: Yes\n+++\n```go\nx := 1\n```\n",
+		"/content/en/news/Off.md":   "MarkdownHighlight: No\n+++\n```go\nx := 1\n```\n",
+	})
+
+	content := string(app.Page("code").Content())
+	parts := []string{
+		`<pre class="chroma">`,
+		`<span class="kn">package</span>`,
+		`<span class="ln">10</span>`,
+		`<span class="line hl"><span class="ln">11</span>`,
+		`<pre><code>plain &lt;text&gt;`,
+		`<pre><code class="language-nosuchlang">other`,
+	}
+	for _, part := range parts {
+		if !strings.Contains(content, part) {
+			t.Fatalf("Content must contain %s\n%s", part, content)
+		}
+	}
+
+	if s := string(app.Page("lines").Content()); !strings.Contains(s, `<span class="ln">1</span>`) {
+		t.Fatal("Line numbers must be turned on by page param", s)
+	}
+	if s := string(app.Page("off").Content()); !strings.Contains(s, `<pre><code class="language-go">`) {
+		t.Fatal("Highlighting must be turned off by page param", s)
+	}
+
+	// Template function uses same hook
+	if s := string(tMdToHTML(app.Page("off"), "```go\nx\n```")); strings.Contains(s, "chroma") {
+		t.Fatal("MdToHTML must use page options", s)
+	}
+
+	buf, err := ioutil.ReadFile(app.PublicPath + "/highlight.css")
+	if err != nil || !strings.Contains(string(buf), ".chroma") {
+		t.Fatal("highlight.css must be written", err, string(buf))
+	}
+}
+
+func Test_HighlightCSS(t *testing.T) {
+	// Highlighting off - no css
+	_, app := tSite(t, map[string]string{
+		"/.mango":                 "MarkdownHighlight: No\n",
+		"/content/en/news/Doc.md": "```go\nx := 1\n```\n",
+	})
+	if _, err := os.Stat(app.PublicPath + "/highlight.css"); err == nil {
+		t.Fatal("highlight.css must not be written when highlighting is off")
+	}
+
+	// Write problems are reported
+	_, app = tSite(t, map[string]string{
+		"/public/highlight.css/x": "not a file",
+		"/content/en/news/Doc.md": "Doc",
+	})
+	isFound := false
+	for _, d := range app.Diagnostics() {
+		if strings.Contains(d.Message, "can't write highlight css") && d.Severity == SeverityError {
+			isFound = true
+		}
+	}
+	if !isFound {
+		t.Fatal("Write error must be reported", app.Diagnostics())
+	}
+}
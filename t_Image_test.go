@@ -0,0 +1,120 @@
+package mango
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Jpeg image with fake EXIF segment
+func tJPEG(width, height int) (withEXIF, without []byte) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{200, 100, 50, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, nil)
+	without = buf.Bytes()
+
+	exif := []byte("Exif\x00\x00GPS 56.9496N 24.1052E")
+	segment := append([]byte{0xFF, 0xE1, 0, byte(len(exif) + 2)}, exif...)
+	withEXIF = append(append(append([]byte{}, without[:2]...), segment...), without[2:]...)
+
+	return withEXIF, without
+}
+
+func Test_StripEXIF(t *testing.T) {
+	withEXIF, without := tJPEG(10, 10)
+
+	if !bytes.Equal(stripEXIF(withEXIF), without) {
+		t.Fatal("EXIF must be removed")
+	}
+	if !bytes.Equal(stripEXIF(without), without) {
+		t.Fatal("Image without EXIF must stay the same")
+	}
+	if s := stripEXIF([]byte("not jpeg")); string(s) != "not jpeg" {
+		t.Fatal("Not jpeg must stay the same")
+	}
+}
+
+func Test_ResizeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for x := 0; x < 300; x++ {
+		for y := 0; y < 200; y++ {
+			c := color.RGBA{0, 0, 0, 255}
+			if x%2 == 0 {
+				c = color.RGBA{200, 200, 200, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	resized := resizeImage(img, 150)
+	if b := resized.Bounds(); b.Dx() != 150 || b.Dy() != 100 {
+		t.Fatal("Incorrect size", b)
+	}
+
+	// Stripes are averaged
+	if c := resized.RGBAAt(10, 10); c.R != 100 || c.A != 255 {
+		t.Fatal("Incorrect color", c)
+	}
+}
+
+func Test_ImagePipeline(t *testing.T) {
+	for mode, scope := range map[string]string{"Move": "images", "Keep": "assets/en"} {
+		photo, _ := tJPEG(300, 150)
+		_, app := tSite(t, map[string]string{
+			"/.mango":               "Assets: " + mode + "\nImageWidths: 100, 400\nStripEXIF: Yes\n",
+			"/content/en/photo.jpg": string(photo),
+			"/content/en/Post.md":   "![photo](photo.jpg)",
+		})
+
+		// Only smaller variants
+		public := app.PublicPath + "/" + scope
+		if _, err := os.Stat(public + "/photo-100w.jpg"); err != nil {
+			t.Fatal(mode, "Variant must be created", err)
+		}
+		if _, err := os.Stat(public + "/photo-400w.jpg"); err == nil {
+			t.Fatal(mode, "Variant wider than image must not be created")
+		}
+
+		// srcset
+		expected := `src="/` + scope + `/photo.jpg" srcset="/` + scope + `/photo-100w.jpg 100w, /` + scope + `/photo.jpg 300w"`
+		if s := string(app.Page("post").Content()); !strings.Contains(s, expected) {
+			t.Fatalf("%s: content must contain [%s]. Found: [%s]", mode, expected, s)
+		}
+
+		// Template func makes any size
+		if url := tImageURL(app.Page("post"), "/"+scope+"/photo.jpg", 50); url != "/"+scope+"/photo-50w.jpg" {
+			t.Fatal(mode, "Incorrect image url", url)
+		}
+		if _, err := os.Stat(public + "/photo-50w.jpg"); err != nil {
+			t.Fatal(mode, "Requested variant must be created", err)
+		}
+		if url := tImageURL(app.Page("post"), "/"+scope+"/photo.jpg", 1000); url != "/"+scope+"/photo.jpg" {
+			t.Fatal(mode, "Original must be used if not wider", url)
+		}
+
+		// Served without EXIF
+		var buf []byte
+		if mode == "Keep" {
+			w := httptest.NewRecorder()
+			NewAppServer(app, 0).fileServer().ServeHTTP(w, httptest.NewRequest("GET", "/assets/en/photo.jpg", nil))
+			buf = w.Body.Bytes()
+		} else {
+			buf, _ = ioutil.ReadFile(public + "/photo.jpg")
+		}
+		if len(buf) == 0 || bytes.Contains(buf, []byte("GPS")) {
+			t.Fatal(mode, "EXIF must be removed from public image")
+		}
+	}
+}
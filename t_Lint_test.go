@@ -0,0 +1,69 @@
+package mango
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Lint(t *testing.T) {
+	// Define files and problems expected in them
+	dir := tWriteSite(t, map[string]string{
+		"/.mango":                      "Domain: example.loc\nCollections: Tags\n",
+		"/content/en/Home.md":          "Title: Home\n+++\nHome",
+		"/content/en/logo.png":         "png",
+		"/content/en/Redirect.md":      "Redirect: no-such-page\n+++\n",
+		"/content/en/Redirect home.md": "Redirect: home\n+++\n",
+		"/content/en/Redirect out.md":  "Redirect: https://example.com\n+++\n",
+		"/content/en/Tagged.md":        "Title: Tagged\nTag: dog\n+++\n",
+		"/content/en/From.md":          "ContentFrom: Keywords:dog\n+++\n",
+		"/content/en/From slug.md":     "ContentFrom: no-such-page\n+++\n",
+		"/content/en/Dup/Home.md":      "Title: Home\n+++\n",
+		"/content/en/Dates.md":         "VisibleTo: 2019-13-45\n+++\n",
+	})
+	problems := []string{
+		"Redirect.md:1: warning: Redirect target \"/no-such-page\" not found",
+		"Tagged.md:2: warning: unknown collection key \"Tag\" (collected is \"Tags\")",
+		"From.md:1: warning: ContentFrom \"Keywords:dog\": unknown collection \"Keywords\"",
+		"From slug.md:1: error: ContentFrom \"no-such-page\": page not found",
+		"en/Home.md: warning: duplicate slug \"home\" renamed to \"home-2\"", // Dup/ is loaded first
+		"Dates.md:1: error: invalid VisibleTo date",
+	}
+
+	cwd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(cwd)
+
+	app, err := OpenApplication(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := app.Lint()
+	if len(list) != len(problems) {
+		t.Fatal("Incorrect problem count", list)
+	}
+	for _, problem := range problems {
+		isFound := false
+		for _, d := range list {
+			if strings.Contains(d.String(), problem) {
+				isFound = true
+				break
+			}
+		}
+		if !isFound {
+			t.Fatalf("Problem [%s] not found in %v", problem, list)
+		}
+	}
+
+	// No side effects
+	if _, err := os.Stat(dir + "/content/en/logo.png"); err != nil {
+		t.Fatal("Assets must not be moved", err)
+	}
+	if _, err := os.Stat(dir + "/public"); !os.IsNotExist(err) {
+		t.Fatal("Public path must not be created", err)
+	}
+	if app.PageCount() != 0 {
+		t.Fatal("Lint must not change loaded content")
+	}
+}
@@ -0,0 +1,96 @@
+package mango
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_MarkdownOptions(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/.mango":                 "MarkdownFootnotes: Yes\nMarkdownHeadingIDPrefix: doc-\n",
+		"/templates/layout.tmpl":  `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/content/en/news/Doc.md": "# Intro\n\nText[^1] \"quoted\"\n\n[^1]: Note\n",
+		"/content/en/news/Poem.md": "MarkdownHardLineBreaks: Yes\nMarkdownSmartypants: No\nMarkdownHeadingIDPrefix:\n+++\n" +
+			"# Poem\n\nFirst\nSecond \"quoted\"\n\n| a |\n|---|\n| 1 |\n",
+		"/content/en/news/Plain.md": "MarkdownTables: No\n+++\n| a |\n|---|\n| 1 |\n",
+	})
+
+	cases := map[string][]string{
+		"doc":  {`<h1 id="doc-intro">Intro</h1>`, `<sup class="footnote-ref"`, `&ldquo;quoted&rdquo;`},
+		"poem": {`<h1 id="poem">Poem</h1>`, "First<br>\nSecond", `&quot;quoted&quot;`, `<table>`},
+	}
+	for slug, parts := range cases {
+		content := string(app.Page(slug).Content())
+		for _, part := range parts {
+			if !strings.Contains(content, part) {
+				t.Fatalf("[%s] Content must contain %s\n%s", slug, part, content)
+			}
+		}
+	}
+	if s := string(app.Page("plain").Content()); strings.Contains(s, "<table>") {
+		t.Fatal("Tables must be turned off by page param", s)
+	}
+
+	// Same options in template
+	page := app.Page("poem")
+	if s := tMdToHTML(page, "# Hi\nA\nB"); s != "<h1 id=\"hi\">Hi</h1>\n\n<p>A<br>\nB</p>\n" {
+		t.Fatal("MdToHTML must use page options", s)
+	}
+
+	// Other renderer
+	app.SetMarkdown(MarkdownRendererFunc(func(src []byte, opts MarkdownOptions) []byte {
+		return append([]byte("<pre>"+opts.HeadingIDPrefix+":"), append(bytes.TrimSpace(src), "</pre>"...)...)
+	}))
+	if s := string(app.Page("doc").Content()); !strings.HasPrefix(s, "<pre>doc-:# Intro") {
+		t.Fatal("Content must be rendered with custom renderer", s)
+	}
+	if s := tMdToHTML(app.Page("doc"), "*x*"); s != template.HTML("<pre>doc-:*x*</pre>") {
+		t.Fatal("MdToHTML must use custom renderer", s)
+	}
+	if s := tMdToHTML("*x*"); s != "<p><em>x</em></p>\n" {
+		t.Fatal("MdToHTML without page must use default renderer", s)
+	}
+}
+
+func Test_MarkdownSetOnOpened(t *testing.T) {
+	dir := tWriteSite(t, map[string]string{
+		"/.mango":                   "",
+		"/content/en/news/Post.md":  "Post",
+		"/content/en/news/logo.png": "png",
+	})
+	renderer := MarkdownRendererFunc(func(src []byte, opts MarkdownOptions) []byte {
+		return append([]byte("<pre>"), src...)
+	})
+
+	// Nothing loaded - nothing to reload
+	app, err := OpenApplication(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetMarkdown(renderer)
+	if _, err := os.Stat(dir + "/content/en/news/logo.png"); err != nil {
+		t.Fatal("Asset must not be moved", err)
+	}
+	if _, err := os.Stat(dir + "/public"); err == nil {
+		t.Fatal("Public files must not be written")
+	}
+	if app.Page("post") != nil {
+		t.Fatal("Content must not be loaded")
+	}
+
+	// Dry run stays dry run
+	app.LoadContentDryRun()
+	app.SetMarkdown(renderer)
+	if s := string(app.Page("post").Content()); !strings.HasPrefix(s, "<pre>Post") {
+		t.Fatal("Content must be loaded again with renderer", s)
+	}
+	if _, err := os.Stat(dir + "/content/en/news/logo.png"); err != nil {
+		t.Fatal("Asset must not be moved on dry run", err)
+	}
+	if _, err := os.Stat(dir + "/public"); err == nil {
+		t.Fatal("Public files must not be written on dry run")
+	}
+}
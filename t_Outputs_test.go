@@ -23,7 +23,7 @@ func Test_CoverOutputFuncs(t *testing.T) {
 	app.Print()
 
 	// PageMap output
-	app.slugPages.Print()
+	app.Snapshot().slugPages.Print()
 
 	// PageList output
 	pages.Print()
@@ -48,7 +48,8 @@ func Test_PageFuncs(t *testing.T) {
 		t.Fatal("ERROR: RemoveParam")
 	}
 
-	if paramCount := len(page.Params()); paramCount != 22 {
+	// +WordCount, ReadingTime, Summary
+	if paramCount := len(page.Params()); paramCount != 25 {
 		t.Fatal("ERROR: Params(): Found:", paramCount)
 	}
 
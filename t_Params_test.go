@@ -1,7 +1,10 @@
 package mango
 
 import (
+	"bytes"
 	"fmt"
+	"html/template"
+	"strings"
 	"testing"
 )
 
@@ -130,3 +133,133 @@ func Test_Params(t *testing.T) {
 	}
 
 }
+
+func Test_NestedParams(t *testing.T) {
+	dir := tWriteSite(t, map[string]string{
+		"/.defaults": `Social:
+  Twitter: mango
+  Github: briiC
+Gallery: [{"Src": "default.jpg"}]
+Menu: [{"Label": "Home", "Items": ["a", "b"]}]
+`,
+		"/Post.md": `Title: Post
+Social:
+  Github: me
+Gallery:
+  - Src: a.jpg
+    Alt: First
+  - Src: b.jpg
+Sizes: [1, 2.5]
+Link: [text](url)
++++
+Content`,
+	})
+
+	params := FileToParams(dir + "/Post.md")
+	cases := map[string]string{
+		"Title":          "Post",
+		"Social.Github":  "me",
+		"Social.Twitter": "mango", // maps are merged
+		"Gallery.0.Src":  "a.jpg",
+		"Gallery.0.Alt":  "First",
+		"Gallery.1.Src":  "b.jpg",
+		"Menu.0.Label":   "Home",
+		"Menu.0.Items":   "a, b",
+		"Sizes":          "1, 2.5",
+		"Link":           "[text](url)", // not JSON
+		"Content":        "Content",
+	}
+	for key, val := range cases {
+		if params[key] != val {
+			printMap("nested", params)
+			t.Fatalf("%q expected to be %q (found %q)", key, val, params[key])
+		}
+	}
+	if _, ok := params["Social"]; ok {
+		t.Fatal("Nested parent must not be param")
+	}
+
+	// Lists are not merged item by item
+	m := mergeParams(
+		map[string]string{"Gallery.0.Src": "a.jpg", "Icon": ""},
+		map[string]string{"Gallery.0.Src": "x.jpg", "Gallery.1.Src": "y.jpg", "Icon.Src": "i.png"},
+	)
+	if len(m) != 2 || m["Gallery.0.Src"] != "a.jpg" || m["Icon"] != "" {
+		t.Fatal("Nested list must be replaced as whole", m)
+	}
+
+	// Nested values in templates
+	page := newPage("Post")
+	page.params = params
+	gallery, ok := page.GetNested("Gallery").([]interface{})
+	if !ok || len(gallery) != 2 {
+		t.Fatal("Gallery must be list", page.GetNested("Gallery"))
+	}
+	if social, ok := page.GetNested("Social").(map[string]interface{}); !ok || social["Github"] != "me" {
+		t.Fatal("Social must be map", page.GetNested("Social"))
+	}
+	if page.GetNested("Title") != "Post" || page.GetNested("None") != nil {
+		t.Fatal("Incorrect plain nested value")
+	}
+
+	tmpl := template.Must(template.New("").Funcs(defaultFuncMap).Parse(
+		`{{ Get . "Gallery.1.Src" }}|{{ range GetNested . "Gallery" }}{{ Get . "Src" }}:{{ .Alt }},{{ end }}|{{ range $k, $v := GetNested . "Social" }}{{ $k }}={{ $v }} {{ end }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, page); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != "b.jpg|a.jpg:First,b.jpg:,|Github=me Twitter=mango " {
+		t.Fatal("Incorrect nested template output", s)
+	}
+}
+
+func Test_ParamOperators(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/.mango":                        "",
+		"/templates/layout.tmpl":         `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/content/en/.subdefaults":       "Author: Mango\nTags: site\n",
+		"/content/en/news/.dir":          "Label: News\nIcon: news.png\n",
+		"/content/en/news/.defaults":     "Tags: dog, cat, mouse\nLicense: MIT\nColor: red\n",
+		"/content/en/news/Add.md":        "+Tags: horse\n+++\nAdd",
+		"/content/en/news/Remove.md":     "-Tags: Cat, mouse\n-- comment: not param\n+++\nRemove",
+		"/content/en/news/Clear.md":      "!License\n!Tags:\n+++\nClear",
+		"/content/en/news/Maybe.md":      "Color?: blue\nSize?: big\n+++\nMaybe",
+		"/content/en/news/Ref.md":        "Icon: {Parent.Icon}\nTitle: More {Parent.Label} by {about.Author}\nBroken: {nosuch.Title}\n+++\nRef",
+		"/content/en/news/sub/Deep.md":   "Icon: {Parent.Parent.Icon}\n+++\nDeep",
+		"/content/en/news/sub/.defaults": "!Color\n",
+		"/content/en/news/.subdefaults":  "Color: green\nLicense: GPL\n",
+		"/content/en/info/.dir":          "Author: Mango\n",
+		"/content/en/info/About.md":      "Author: {Parent.Author}!\n+++\nAbout",
+	})
+
+	cases := map[string]map[string]string{
+		"add":    {"Tags": "dog, cat, mouse, horse", "+Tags": ""},
+		"remove": {"Tags": "dog", "-Tags": "", "--": ""},
+		"clear":  {"Tags": "", "License": "", "Author": "Mango", "!License": ""},
+		"maybe":  {"Color": "red", "Size": "big", "Color?": ""},
+		"ref":    {"Icon": "news.png", "Title": "More News by Mango!", "Broken": "{nosuch.Title}"},
+		"deep":   {"Icon": "news.png", "Color": "", "License": "GPL", "Author": "Mango"},
+	}
+	for slug, cParams := range cases {
+		p := app.Page(slug)
+		if p == nil {
+			t.Fatal("Page not found", slug)
+		}
+		for key, val := range cParams {
+			if p.Get(key) != val {
+				p.Print()
+				t.Fatalf("%s: %q expected to be %q (found %q)", slug, key, val, p.Get(key))
+			}
+		}
+	}
+
+	isFound := false
+	for _, d := range app.Diagnostics() {
+		if strings.HasSuffix(d.Path, "Ref.md") && d.Line == 3 && strings.Contains(d.Message, "{nosuch.Title}") {
+			isFound = true
+		}
+	}
+	if !isFound {
+		t.Fatal("Broken reference must be reported", app.Diagnostics())
+	}
+}
@@ -0,0 +1,80 @@
+package mango
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func Test_Query(t *testing.T) {
+	dir, app := tSite(t, map[string]string{
+		"/content/en/pets/Rex.md":     "Tags: dog, good boy\nCategories: House pets\nDate: 2024-03-01\nWeight: 30\n+++\nRex",
+		"/content/en/pets/Bim.md":     "Tags: dog\nCategories: Farm\nDate: 2023-05-01\nWeight: 8\n+++\nBim",
+		"/content/en/pets/Tom.md":     "Tags: cat\nCategories: House pets\nDate: 2024-01-15\nWeight: 4\n+++\nTom",
+		"/content/en/pets/Old.md":     "Tags: dog\nIsUnlisted: Yes\nDate: 2020-01-01\n+++\nOld",
+		"/content/en/lists/Dogs.md":   "ContentFrom: ?Tags:dog -IsUnlisted:Yes sort:-Date\n+++\n",
+		"/content/en/lists/Broken.md": "ContentFrom: ?sort:Date\n+++\n",
+		"/content/lv/zveri/Reksis.md": "Tags: dog\n+++\nReksis",
+	})
+
+	slugs := func(pages PageList) string {
+		var arr []string
+		for _, p := range pages {
+			arr = append(arr, p.Get("Slug"))
+		}
+		return strings.Join(arr, ",")
+	}
+
+	for query, expected := range map[string]string{
+		`Tags:dog`:                            "bim,old,rex,reksis",
+		`Tag:DOG -IsUnlisted:Yes`:             "bim,rex,reksis",
+		`Tags:"good boy"`:                     "rex",
+		`Categories:"House pets" sort:Weight`: "tom,rex",
+		`Date>2024-01-01 sort:-Date`:          "rex,tom",
+		`Date>=2023-05-01 Date<2024-02-01`:    "bim,tom",
+		`Weight>5 sort:-Weight`:               "rex,bim",
+		`Weight:* sort:Weight limit:2`:        "tom,bim",
+		`Tags:dog in:en sort:Slug`:            "bim,rex", // Walk skips unlisted
+		`Tags:dog Lang:lv`:                    "reksis",
+		`Categories=Farm`:                     "bim",
+		`Categories!=Farm Weight:*`:           "rex,tom",
+		`nope`:                                "",
+		`limit:0 Tags:dog`:                    "",
+		`in:no-such-page`:                     "",
+	} {
+		if s := slugs(app.Query(query)); s != expected {
+			t.Errorf("[%s] found [%s]. Expected [%s]", query, s, expected)
+		}
+	}
+
+	// Sub-pages
+	if s := slugs(app.Page("en").Query("Weight<10 sort:Weight")); s != "tom,bim" {
+		t.Fatal("Incorrect sub-pages", s)
+	}
+
+	// Template
+	if s := slugs(tQuery(app.Page("rex"), "Tags:cat")); s != "tom" {
+		t.Fatal("Incorrect template query", s)
+	}
+
+	// List page
+	if s := string(app.Page("dogs").Content()); !strings.Contains(s, "<p>Rex</p>\n\n<p>Bim</p>\n\n<p>Reksis</p>") {
+		t.Fatal("Incorrect list page content", s)
+	}
+	found := false
+	for _, d := range app.Diagnostics() {
+		if strings.HasSuffix(d.Path, "Broken.md") && strings.Contains(d.Message, "no filters") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("Broken query must be reported", app.Diagnostics())
+	}
+
+	// List page follows changes
+	ioutil.WriteFile(dir+"/content/en/pets/Max.md", []byte("Tags: dog\nDate: 2025-01-01\n+++\nMax"), 0644)
+	app.ReloadPath(dir + "/content/en/pets/Max.md")
+	if s := string(app.Page("dogs").Content()); !strings.HasPrefix(s, "\n<p>Max</p>") {
+		t.Fatal("New page must be in list page", s)
+	}
+}
@@ -0,0 +1,93 @@
+package mango
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_ReloadPath(t *testing.T) {
+	app, _ := NewApplication()
+	count := app.PageCount()
+
+	dir := app.ContentPath + "/1_en/top-menu/2_News"
+	fpath := dir + "/9_Reloaded.md"
+	fpathList := dir + "/Reloaded list.md"
+	defer os.Remove(fpath)
+	defer os.Remove(fpathList)
+
+	// New files
+	ioutil.WriteFile(fpath, []byte("Tags: reloaded\n+++\nFirst version"), 0644)
+	ioutil.WriteFile(fpathList, []byte("ContentFrom: Tags:reloaded\n+++\n"), 0644)
+	app.ReloadPath(fpath, fpathList)
+
+	if c := app.PageCount(); c != count+2 {
+		t.Fatal("Page count must increase by 2. Found:", c)
+	}
+	p := app.Page("reloaded")
+	if p == nil || p.Parent != app.Page("news") || !p.IsEqual("BreadCrumbs", "en / en-top-menu / news / ") {
+		t.Fatal("New page must be added to tree")
+	}
+	if news := app.Page("news"); news.Pages[0] != p {
+		// News are sorted in reverse
+		news.Pages.Print()
+		t.Fatal("New page must be sorted by SortNr")
+	}
+	if len(app.CollectionPages("Tags", "reloaded")) != 1 {
+		t.Fatal("New page must be added to collection")
+	}
+	if s := string(app.Page("reloaded-list").Content()); !strings.Contains(s, "First version") {
+		t.Fatalf("ContentFrom must be loaded. Found: [%s]", s)
+	}
+
+	// Changed file
+	// Only changed page and pages depending on it are reloaded
+	hello := app.Page("hello")
+	ioutil.WriteFile(fpath, []byte("Tags: reloaded\n+++\nSecond version"), 0644)
+	app.ReloadPath(fpath)
+
+	// Copied to new snapshot, not parsed again
+	if hello2 := app.Page("hello"); hello2 == hello || &hello2.rawContent()[0] != &hello.rawContent()[0] {
+		t.Fatal("Not changed pages must stay the same")
+	}
+	if s := string(app.Page("reloaded").Content()); !strings.Contains(s, "Second version") {
+		t.Fatalf("Changed page must be reloaded. Found: [%s]", s)
+	}
+	if s := string(app.Page("reloaded-list").Content()); !strings.Contains(s, "Second version") || strings.Contains(s, "First version") {
+		t.Fatalf("Dependent page must be reloaded. Found: [%s]", s)
+	}
+
+	// Removed file
+	os.Remove(fpath)
+	app.ReloadPath(fpath)
+
+	if app.Page("reloaded") != nil || app.PageCount() != count+1 {
+		t.Fatal("Removed page must not be found")
+	}
+	if len(app.CollectionPages("Tags", "reloaded")) != 0 {
+		t.Fatal("Removed page must be removed from collection")
+	}
+	for _, p := range app.Page("news").Pages {
+		if p.IsEqual("Slug", "reloaded") {
+			t.Fatal("Removed page must be removed from parent")
+		}
+	}
+	if s := string(app.Page("reloaded-list").Content()); strings.Contains(s, "version") {
+		t.Fatalf("Dependent page must be reloaded. Found: [%s]", s)
+	}
+
+	// Directory config changes reloads all directory
+	news := app.Page("news")
+	app.ReloadPath(dir + "/.dir")
+	if app.Page("news") == news || app.Page("hello") == hello || app.Page("hello").Parent != app.Page("news") {
+		t.Fatal("Directory must be reloaded with sub-pages")
+	}
+
+	// Language folder changes reloads everything
+	en := app.Page("en")
+	app.ReloadPath(app.ContentPath + "/1_en/.defaults")
+	if app.Page("en") == en {
+		t.Fatal("Whole content must be reloaded")
+	}
+}
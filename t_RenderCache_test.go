@@ -0,0 +1,161 @@
+package mango
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_RenderCache(t *testing.T) {
+	dir, app := tSite(t, map[string]string{
+		"/.mango":                 "CacheSize: 1MB\n",
+		"/templates/layout.tmpl":  `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/content/en/news/A.md":   "Apple",
+		"/content/en/news/B.md":   "ContentFrom: a\n+++\n",
+		"/content/en/news/C.md":   "Tags: dog\n+++\nCat",
+		"/content/en/news/D.md":   "Banana",
+		"/content/en/pets/Dog.md": "ContentFrom: Tag:dog\n+++\n",
+	})
+	srv := NewAppServer(app, 0)
+	ts := httptest.NewServer(srv.preStart())
+	defer ts.Close()
+
+	get := func(slug, expected string) {
+		if _, body := tHTTPGet(t, ts.URL+"/en/"+slug); !strings.Contains(body, expected) {
+			t.Fatalf("%s: expected [%s] in [%s]", slug, expected, body)
+		}
+	}
+	getAll := func() {
+		get("a", "<p>A")
+		get("b", "<p>A")
+		get("c", "Cat")
+		get("d", "Banana")
+		get("dog", "Cat")
+	}
+	isCached := func(slug string) bool {
+		return srv.cache.get(" /en/"+slug) != nil || srv.cache.get("en /en/"+slug) != nil
+	}
+
+	getAll()
+	getAll()
+	if stats := srv.CacheStats(); stats.Hits != 5 || stats.Misses != 5 || stats.Entries != 5 || stats.Size == 0 {
+		t.Fatalf("Incorrect stats: %+v", stats)
+	}
+
+	// Page and pages with content from it
+	ioutil.WriteFile(dir+"/content/en/news/A.md", []byte("Avocado"), 0644)
+	app.ReloadPath(dir + "/content/en/news/A.md")
+	if isCached("a") || isCached("b") {
+		t.Fatal("Changed page and its dependents must be dropped")
+	}
+	if !isCached("c") || !isCached("d") || !isCached("dog") {
+		t.Fatal("Other pages must stay cached")
+	}
+	get("a", "Avocado")
+	get("b", "Avocado")
+
+	// Page joins collection
+	get("a", "Avocado")
+	get("c", "Cat")
+	get("dog", "Cat")
+	ioutil.WriteFile(dir+"/content/en/news/D.md", []byte("Tags: dog\n+++\nBanana"), 0644)
+	app.ReloadPath(dir + "/content/en/news/D.md")
+	if isCached("c") || isCached("dog") {
+		t.Fatal("Collection peers must be dropped")
+	}
+	if !isCached("a") {
+		t.Fatal("Pages not in collection must stay cached")
+	}
+	get("dog", "Banana")
+
+	// Templates
+	ioutil.WriteFile(dir+"/templates/layout.tmpl", []byte(`{{ define "layout" }}NEW {{ Content . }}{{ end }}`), 0644)
+	if err := srv.ReloadTemplates(); err != nil {
+		t.Fatal(err)
+	}
+	if srv.CacheStats().Entries != 0 {
+		t.Fatal("Templates change must drop all pages")
+	}
+	get("d", "NEW <p>Banana")
+
+	// Full reload
+	getAll()
+	app.LoadContent()
+	if srv.CacheStats().Entries != 0 {
+		t.Fatal("Content load must drop all pages")
+	}
+
+	// Least recently used pages are dropped
+	ioutil.WriteFile(dir+"/content/en/news/D.md", []byte("Banana"), 0644)
+	app.LoadContent()
+	srv.CacheSize = 40
+	get("a", "Avocado") // 19 bytes
+	get("d", "Banana")  // 18 bytes
+	get("a", "Avocado")
+	get("c", "Cat") // 15 bytes
+	if stats := srv.CacheStats(); stats.Entries != 2 || stats.Size > srv.CacheSize || isCached("d") {
+		t.Fatalf("Oldest page must be dropped: %+v", stats)
+	}
+}
+
+func Test_RenderCacheMenu(t *testing.T) {
+	dir, app := tSite(t, map[string]string{
+		"/.mango": "CacheSize: 1MB\n",
+		"/templates/layout.tmpl": `{{ define "layout" }}` +
+			`{{ range .Parent.Pages }}[{{ Get . "Title" }}]{{ end }}{{ Content . }}{{ end }}`,
+		"/content/en/news/A.md": "Apple",
+		"/content/en/news/B.md": "Banana",
+	})
+	srv := NewAppServer(app, 0)
+	ts := httptest.NewServer(srv.preStart())
+	defer ts.Close()
+
+	tHTTPGet(t, ts.URL+"/en/a")
+	if srv.CacheStats().Entries != 1 {
+		t.Fatal("Page must be cached")
+	}
+
+	// Added page must be in menu of other pages
+	ioutil.WriteFile(dir+"/content/en/news/C.md", []byte("Cherry"), 0644)
+	app.ReloadPath(dir + "/content/en/news/C.md")
+	if _, body := tHTTPGet(t, ts.URL+"/en/a"); !strings.Contains(body, "[C]") {
+		t.Fatal("Menu must have added page", body)
+	}
+
+	// Renamed page
+	ioutil.WriteFile(dir+"/content/en/news/C.md", []byte("Title: Cranberry\n+++\nCranberry"), 0644)
+	app.ReloadPath(dir + "/content/en/news/C.md")
+	if _, body := tHTTPGet(t, ts.URL+"/en/a"); !strings.Contains(body, "[Cranberry]") {
+		t.Fatal("Menu must have new title", body)
+	}
+
+	// Removed page
+	os.Remove(dir + "/content/en/news/C.md")
+	app.ReloadPath(dir + "/content/en/news/C.md")
+	if _, body := tHTTPGet(t, ts.URL+"/en/a"); strings.Contains(body, "Cranberry") {
+		t.Fatal("Menu must not have removed page", body)
+	}
+}
+
+func Test_RenderCacheOff(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/templates/layout.tmpl": `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/content/en/news/A.md":  "Apple",
+	})
+	srv := NewAppServer(app, 0)
+	ts := httptest.NewServer(srv.preStart())
+	defer ts.Close()
+
+	tHTTPGet(t, ts.URL+"/en/a")
+	if srv.CacheSize != 0 || srv.CacheStats().Entries != 0 {
+		t.Fatal("Cache must be off by default")
+	}
+
+	for in, n := range map[string]int64{"32MB": 32 << 20, "512 kb": 512 << 10, "1000": 1000, "": 0, "lots": 0, "-1": 0} {
+		if size := parseByteSize(in); size != n {
+			t.Fatal("Incorrect size of", in, size)
+		}
+	}
+}
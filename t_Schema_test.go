@@ -0,0 +1,116 @@
+package mango
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_TypedParams(t *testing.T) {
+	page := newPage("")
+	page.Set("Weight", "12")
+	page.Set("Price", "1.5")
+	page.Set("IsNew", "Yes")
+	page.Set("IsOld", "false")
+	page.Set("Date", "2024-01-02")
+	page.Set("Timeout", "1h30m")
+	page.Set("Tags", "dog, cat,, mouse")
+	page.Set("Bad", "abc")
+
+	if page.GetInt("Weight") != 12 || page.GetInt("Bad") != 0 || page.GetInt("None") != 0 {
+		t.Fatal("Incorrect int")
+	}
+	if page.GetFloat("Price") != 1.5 || page.GetFloat("Bad") != 0 {
+		t.Fatal("Incorrect float")
+	}
+	if !page.GetBool("IsNew") || page.GetBool("IsOld") || page.GetBool("Bad") || page.GetBool("None") {
+		t.Fatal("Incorrect bool")
+	}
+	if dt := page.GetTime("Date"); dt.Year() != 2024 || dt.Month() != 1 || dt.Day() != 2 {
+		t.Fatal("Incorrect time", dt)
+	}
+	if !page.GetTime("Bad").IsZero() || !page.GetTime("None").IsZero() {
+		t.Fatal("Invalid time must be zero")
+	}
+	if page.GetDuration("Timeout") != 90*time.Minute || page.GetDuration("Bad") != 0 {
+		t.Fatal("Incorrect duration")
+	}
+	if list := page.GetList("Tags"); strings.Join(list, "|") != "dog|cat|mouse" {
+		t.Fatal("Incorrect list", list)
+	}
+}
+
+func Test_Schema(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/.mango":                "",
+		"/templates/layout.tmpl": `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/content/en/news/.schema": "Date: time, required\n" +
+			"Weight: int\n" +
+			"Status: string, required, draft|published\n" +
+			"Tags: list, dog|cat\n" +
+			"Extra: int, sometimes\n",
+		"/content/en/news/Good.md":       "Date: 2024-01-02\nStatus: draft\nTags: dog, cat\n+++\nGood",
+		"/content/en/news/Bad.md":        "Weight: heavy\nStatus: gone\nTags: dog, mouse\n+++\nBad",
+		"/content/en/news/sub/Nested.md": "Status: published\n+++\nNested",
+		"/content/en/other/NoSchema.md":  "Weight: heavy\n+++\nNo schema",
+	})
+
+	problems := []string{
+		"Bad.md: error: required param \"Date\" not set",
+		"Bad.md:1: error: param \"Weight\": \"heavy\" is not int",
+		"Bad.md:2: error: param \"Status\": \"gone\" is not one of draft, published",
+		"Bad.md:3: error: param \"Tags\": \"mouse\" is not one of dog, cat",
+		"Nested.md: error: required param \"Date\" not set",
+		".schema:5: warning: unknown schema rule \"sometimes\"",
+	}
+	list := app.Diagnostics()
+	for _, problem := range problems {
+		isFound := false
+		for _, d := range list {
+			if strings.Contains(d.String(), problem) {
+				isFound = true
+				break
+			}
+		}
+		if !isFound {
+			t.Fatalf("Problem [%s] not found in %v", problem, list)
+		}
+	}
+
+	for _, d := range list {
+		if strings.Contains(d.Path, "Good.md") || strings.Contains(d.Path, "NoSchema.md") {
+			t.Fatal("Valid page must have no problems", d)
+		}
+	}
+
+	// Schema is not a page
+	if app.Page("schema") != nil {
+		t.Fatal(".schema must not be loaded as page")
+	}
+}
+
+func Test_SchemaRoot(t *testing.T) {
+	dir, app := tSite(t, map[string]string{
+		"/.schema":                  "Date: time, required\n",
+		"/content/.schema":          "Weight: int\n",
+		"/content/en/news/Page.md":  "Weight: 1\n+++\nPage",
+		"/content/en/news/Heavy.md": "Weight: heavy\n+++\nHeavy",
+	})
+
+	for _, d := range app.Diagnostics() {
+		if strings.Contains(d.Message, "Date") {
+			t.Fatal("Schema above ContentPath must not be used", d)
+		}
+	}
+	if len(app.Diagnostics()) != 1 {
+		t.Fatal("Schema in ContentPath must be used", app.Diagnostics())
+	}
+
+	// Changed schema is read again on reload
+	ioutil.WriteFile(dir+"/content/.schema", []byte("Weight: string\n"), 0644)
+	app.ReloadPath(dir + "/content/en/news/Heavy.md")
+	if list := app.Diagnostics(); len(list) != 0 {
+		t.Fatal("Reloaded page must be checked by new schema", list)
+	}
+}
@@ -0,0 +1,73 @@
+package mango
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_SearchServer(t *testing.T) {
+	files := map[string]string{
+		"/.mango":                 "SearchURL: /{Lang}/search\n",
+		"/content/en/.defaults":   "SearchSize: 2\n",
+		"/content/en/news/Pie.md": "Title: Apple pie\n+++\nBake it",
+		"/templates/layout.tmpl": `{{ define "layout" }}{{ $Page := . }}` +
+			`{{ if eq (Get $Page "Template") "search" }}` +
+			`[{{ Get $Page "SearchTerm" }}] {{ Get $Page "PNum" }}/{{ Get $Page "PTotalPages" }}` +
+			`{{ range SearchResults $Page }} {{ .Page.Get "Slug" }}: {{ .Snippet }}{{ end }}` +
+			`{{ else }}{{ Content $Page }}{{ end }}{{ end }}`,
+	}
+	for i := 1; i <= 3; i++ {
+		files[fmt.Sprintf("/content/en/news/Apple%d.md", i)] = fmt.Sprintf("Title: Apple %d\n+++\nGreen apples", i)
+	}
+	_, app := tSite(t, files)
+	srv := NewAppServer(app, 0)
+	ts := httptest.NewServer(srv.preStart())
+	defer ts.Close()
+
+	// Html (paged)
+	res, body := tHTTPGet(t, ts.URL+"/en/search?q=green+apples&p=2")
+	if res.StatusCode != 200 || !strings.HasPrefix(body, "[green apples] 2/2 apple3: <mark>Green</mark> <mark>apples</mark>") {
+		t.Fatal("Incorrect search page", res.StatusCode, body)
+	}
+	if res.Header.Get("Vary") != "Accept" {
+		t.Fatal("Search page must vary by Accept", res.Header)
+	}
+
+	// Search is not a page slug
+	if _, body := tHTTPGet(t, ts.URL+"/en/pie"); !strings.Contains(body, "Bake it") {
+		t.Fatal("Pages must be served", body)
+	}
+
+	// Json
+	req, _ := http.NewRequest("GET", ts.URL+"/en/search?q=pie", nil)
+	req.Header.Set("Accept", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var data struct {
+		Query   string
+		Total   int
+		Results []struct {
+			Title   string
+			URL     string
+			Snippet string
+			Score   float64
+		}
+	}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Query != "pie" || data.Total != 1 || len(data.Results) != 1 {
+		t.Fatalf("Incorrect json: %+v", data)
+	}
+	if r := data.Results[0]; r.Title != "Apple pie" || r.URL != "/en/pie" || r.Snippet != "Bake it" || r.Score <= 0 {
+		t.Fatalf("Incorrect json result: %+v", r)
+	}
+}
@@ -0,0 +1,123 @@
+package mango
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Stemming(t *testing.T) {
+	for _, tc := range []struct{ lang, word, stem string }{
+		{"en", "playing", "play"},
+		{"en", "cats", "cat"},
+		{"en", "apples", "appl"},
+		{"en", "apple", "appl"},
+		{"en", "baking", "bak"},
+		{"en", "boxes", "box"},
+		{"en", "cities", "city"},
+		{"en", "class", "class"},
+		{"en", "the", ""}, // stop word
+		{"en", "is", ""},
+		{"lv", "suņiem", "suņ"},
+		{"lv", "suņi", "suņ"},
+		{"lv", "un", ""},
+		{"ru", "собаками", "собак"},
+		{"ru", "собака", "собак"},
+		{"ru", "и", ""},
+		{"xx", "cats", "cats"}, // unknown language
+		{"en", "gas", "gas"},   // too short to stem
+	} {
+		if stem := stemWord(tc.word, tc.lang); stem != tc.stem {
+			t.Fatalf("%s [%s] --> [%s]. Expected [%s]", tc.lang, tc.word, stem, tc.stem)
+		}
+	}
+
+	tokens := tokenize("Rūķis, <b>dog</b> 42!")
+	if len(tokens) != 5 || tokens[0].stem != "rūķis" || tokens[0].end != len("Rūķis") || tokens[4].stem != "42" {
+		t.Fatal("Incorrect tokens", tokens)
+	}
+
+	if s := htmlToText("<p>Fish &amp; <b>chips</b></p><script>var x = 1;</script>\n\n<p>Yes</p>"); s != "Fish & chips Yes" {
+		t.Fatal("Incorrect text", s)
+	}
+}
+
+func Test_SearchIndex(t *testing.T) {
+	dir, app := tSite(t, map[string]string{
+		"/content/en/news/Apples.md":    "Title: Green apples\n+++\nEat them <b>fresh</b>.",
+		"/content/en/news/Pie.md":       "Title: Pie\nTags: apples\n+++\nBake an apple pie with green apples.",
+		"/content/en/news/Orchard.md":   "Title: Orchard\n+++\n" + strings.Repeat("Trees grow here. ", 20) + "Apples are picked in autumn & <stored>.",
+		"/content/en/news/Bold.md":      "Title: Bold\n+++\n<b>Bold</b> text",
+		"/content/en/news/Hidden.md":    "Title: Hidden apples\nIsUnlisted: Yes\n+++\n",
+		"/content/lv/jaunumi/Suņi.md":   "Title: Suņi\n+++\nMūsu suņiem patīk skriet.",
+		"/content/ru/novosti/Sobaki.md": "Title: Собаки\n+++\nНаши собаки любят бегать.",
+	})
+
+	slugs := func(pages PageList) string {
+		var arr []string
+		for _, p := range pages {
+			arr = append(arr, p.Get("Slug"))
+		}
+		return strings.Join(arr, ",")
+	}
+
+	// Title > Tags > body
+	if s := slugs(app.Search("en", "apple")); s != "apples,pie,orchard" {
+		t.Fatal("Incorrect ranking:", s)
+	}
+
+	// All words must be found
+	if s := slugs(app.Search("en", "green pie")); s != "pie" {
+		t.Fatal("Incorrect results:", s)
+	}
+
+	// Phrase
+	if s := slugs(app.Search("en", `"apple pie"`)); s != "pie" {
+		t.Fatal("Incorrect phrase results:", s)
+	}
+	if s := slugs(app.Search("en", `"pie apple"`)); s != "" {
+		t.Fatal("Phrase words must be in order:", s)
+	}
+
+	// Tags are not html
+	if s := slugs(app.Search("en", "b")); s != "" {
+		t.Fatal("Html tags must not be found:", s)
+	}
+
+	// Other languages
+	if s := slugs(app.Search("lv", "suņiem")); s != "suni" {
+		t.Fatal("Incorrect lv results:", s)
+	}
+	if s := slugs(app.Search("ru", "собака")); s != "sobaki" {
+		t.Fatal("Incorrect ru results:", s)
+	}
+	if s := slugs(app.Search("en", "собака")); s != "" {
+		t.Fatal("Search must be under given page:", s)
+	}
+
+	// Snippet
+	results := app.SearchResults("en", "apples")
+	if len(results) != 3 || results[0].Score <= results[1].Score {
+		t.Fatal("Incorrect results", results)
+	}
+	snippet := string(results[2].Snippet)
+	if !strings.HasPrefix(snippet, "… ") || !strings.Contains(snippet, "<mark>Apples</mark> are picked in autumn &amp; .") {
+		t.Fatal("Incorrect snippet", snippet)
+	}
+
+	// Reload
+	ioutil.WriteFile(dir+"/content/en/news/Bold.md", []byte("Title: Bold\n+++\nApple juice"), 0644)
+	app.ReloadPath(dir + "/content/en/news/Bold.md")
+	if s := slugs(app.Search("en", "juice")); s != "bold" {
+		t.Fatal("Changed page must be found:", s)
+	}
+	os.Remove(dir + "/content/en/news/Pie.md")
+	app.ReloadPath(dir + "/content/en/news/Pie.md")
+	if s := slugs(app.Search("en", "pie")); s != "" {
+		t.Fatal("Removed page must not be found:", s)
+	}
+	if s := slugs(app.Search("en", "apple")); s != "apples,bold,orchard" {
+		t.Fatal("Incorrect results after reload:", s)
+	}
+}
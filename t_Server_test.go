@@ -81,13 +81,13 @@ func Test_Server(t *testing.T) {
 
 		// HTTP codecheck
 		if strconv.Itoa(res.StatusCode) != m["Code"] {
-			ma.App.slugPages.Print()
+			ma.App.Snapshot().slugPages.Print()
 			t.Fatalf("Request[%s] status code should be [%s] not [%d]", url, m["Code"], res.StatusCode)
 		}
 
 		// Body check
 		if strings.Index(string(body), m["Body"]) == -1 {
-			ma.App.slugPages.Print()
+			ma.App.Snapshot().slugPages.Print()
 			t.Fatalf("Request[%s] body should contain [%s] but found [%s]", url, m["Body"], string(body))
 		}
 	}
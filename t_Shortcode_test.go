@@ -0,0 +1,133 @@
+package mango
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_Shortcodes(t *testing.T) {
+	dir, app := tSite(t, map[string]string{
+		"/.mango":                        "Collections: Tags\n",
+		"/templates/layout.tmpl":         `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/templates/card.tmpl":           `{{ define "card" }}<div class="card">{{ .Params.title }} on {{ Get .Page "Title" }}</div>{{ end }}`,
+		"/public/images/trip/b.jpg":      "jpg",
+		"/public/images/trip/a.png":      "png",
+		"/public/images/trip/a-480w.png": "png",
+		"/public/images/trip/notes.txt":  "txt",
+		"/content/en/.translations":      "Read more: Read more\n",
+		"/content/lv/.translations":      "Read more: Lasīt vairāk\n",
+		"/content/en/news/Dog.md":        "Tags: dog\n+++\nWoof",
+		"/content/en/news/Puppy.md":      "Tags: dog\n+++\nSmall woof",
+		"/content/en/news/Post.md": "Title: Post\n+++\n# Post\n\n" +
+			"{{< youtube dQw4w9WgXcQ >}}\n\n" +
+			"Photos: {{< gallery dir=\"images/trip\" >}}\n\n" +
+			"{{< collection Tags dog sort=\"Title\" >}}\n\n" +
+			"{{< card title=\"Hello, world\" >}}\n\n" +
+			"{{< nosuch >}}\n",
+		"/content/lv/zinas/Raksts.md": "{{< T \"Read more\" >}}",
+	})
+	srv := NewAppServer(app, 0)
+	srv.AddShortcode("card", "card")
+	app.AddShortcode("upper", func(sc *Shortcode) (template.HTML, error) {
+		return template.HTML(strings.ToUpper(sc.Get("text", 0))), nil
+	})
+
+	content := string(app.Page("post").Content())
+	parts := []string{
+		`<h1 id="post">Post</h1>`,
+		`<iframe src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"`,
+		`<div class="gallery"><a href="/images/trip/a.png"><img src="/images/trip/a.png" alt="a" loading="lazy"></a><a href="/images/trip/b.jpg">`,
+		`<ul class="collection"><li><a href="/en/dog">Dog</a></li><li><a href="/en/puppy">Puppy</a></li></ul>`,
+		`<div class="card">Hello, world on Post</div>`,
+		`{{&lt; nosuch &gt;}}`,
+	}
+	for _, part := range parts {
+		if !strings.Contains(content, part) {
+			t.Fatalf("Content must contain %s\n%s", part, content)
+		}
+	}
+	if strings.Contains(content, "a-480w.png") || strings.Contains(content, "notes.txt") {
+		t.Fatal("Gallery must have only original images", content)
+	}
+
+	if s := string(app.Page("raksts").Content()); !strings.Contains(s, "Lasīt vairāk") {
+		t.Fatal("Shortcode must be translated", s)
+	}
+
+	isFound := false
+	for _, d := range app.Diagnostics() {
+		if strings.HasSuffix(d.Path, "Post.md") && strings.Contains(d.Message, `"nosuch"`) {
+			isFound = true
+		}
+	}
+	if !isFound {
+		t.Fatal("Unknown shortcode must be reported", app.Diagnostics())
+	}
+
+	// Added later - rendered with fresh content
+	ioutil.WriteFile(dir+"/content/en/news/Post.md", []byte("{{< upper \"shout\" >}}"), 0644)
+	app.ReloadPath(dir + "/content/en/news/Post.md")
+
+	ts := httptest.NewServer(srv.preStart())
+	defer ts.Close()
+	if _, body := tHTTPGet(t, ts.URL+"/en/post"); !strings.Contains(body, "SHOUT") {
+		t.Fatal("Added shortcode must be rendered", body)
+	}
+}
+
+func Test_ShortcodesInCode(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/content/en/news/Docs.md": "# Docs\n\n" +
+			"Use `{{< youtube abc >}}` in text.\n\n" +
+			"```\n{{< youtube fenced >}}\n```\n\n" +
+			"~~~~\n```\n{{< youtube tilde >}}\n~~~~\n\n" +
+			"Escaped: {{</* youtube escaped */>}}\n\n" +
+			"{{< youtube real >}}\n",
+	})
+
+	content := string(app.Page("docs").Content())
+	parts := []string{
+		"<code>{{&lt; youtube abc &gt;}}</code>",
+		"{{&lt; youtube fenced &gt;}}",
+		"{{&lt; youtube tilde &gt;}}",
+		"Escaped: {{&lt; youtube escaped &gt;}}",
+		"embed/real",
+	}
+	for _, part := range parts {
+		if !strings.Contains(content, part) {
+			t.Fatalf("Content must contain %s\n%s", part, content)
+		}
+	}
+	for _, id := range []string{"abc", "fenced", "tilde", "escaped"} {
+		if strings.Contains(content, "embed/"+id) {
+			t.Fatal("Shortcode in code must not be rendered:", id, content)
+		}
+	}
+}
+
+func Test_ShortcodeTemplatesConcurrent(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/templates/layout.tmpl":  `{{ define "layout" }}{{ Content . }}{{ end }}`,
+		"/templates/card.tmpl":    `{{ define "card" }}<div class="card">{{ .Params.title }}</div>{{ end }}`,
+		"/content/en/news/One.md": `{{< card title="One" >}}`,
+	})
+	srv := NewAppServer(app, 0)
+	srv.AddShortcode("card", "card")
+
+	// First renders load templates (go test -race)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s := string(app.Page("one").Content()); !strings.Contains(s, `<div class="card">One</div>`) {
+				t.Error("Shortcode must be rendered", s)
+			}
+		}()
+	}
+	wg.Wait()
+}
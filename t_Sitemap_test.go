@@ -0,0 +1,141 @@
+package mango
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tSitemapSite(t *testing.T, config string) *Application {
+	_, app := tSite(t, map[string]string{
+		"/.mango":                      "Domain: https://example.loc\n" + config,
+		"/content/en/site/About.md":    "TranslationKey: about\nPriority: 0.8\nChangeFreq: Weekly\n+++\nAbout",
+		"/content/en/site/Contact.md":  "Priority: 2\nChangeFreq: sometimes\n+++\nContact",
+		"/content/en/site/Secret.md":   "IsUnlisted: Yes\n+++\nSecret",
+		"/content/en/site/Hidden.md":   "IsSitemap: No\n+++\nHidden",
+		"/content/en/site/Moved.md":    "Redirect: /en/about\n+++\n",
+		"/content/lv/site/Par-mums.md": "TranslationKey: about\n+++\nPar mums",
+	})
+	return app
+}
+
+func Test_Sitemap(t *testing.T) {
+	app := tSitemapSite(t, "")
+
+	// Everything fits in one file
+	buf, _ := ioutil.ReadFile(app.PublicPath + "/sitemap.xml")
+	sitemap := string(buf)
+	parts := []string{
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">`,
+		"<loc>https://example.loc/en/about</loc>",
+		"<changefreq>weekly</changefreq>\n    <priority>0.8</priority>",
+		`<xhtml:link rel="alternate" hreflang="en" href="https://example.loc/en/about"></xhtml:link>`,
+		`<xhtml:link rel="alternate" hreflang="lv" href="https://example.loc/lv/par-mums"></xhtml:link>`,
+		"<loc>https://example.loc/en/contact</loc>",
+		"<loc>https://example.loc/lv/par-mums</loc>",
+	}
+	for _, part := range parts {
+		if !strings.Contains(sitemap, part) {
+			t.Fatalf("sitemap.xml must contain %s\n%s", part, sitemap)
+		}
+	}
+	if strings.Contains(sitemap, "<sitemapindex") {
+		t.Fatal("sitemap.xml must not be index", sitemap)
+	}
+	if strings.Index(sitemap, "/en/about<") > strings.Index(sitemap, "/en/contact<") ||
+		strings.Index(sitemap, "/en/contact<") > strings.Index(sitemap, "<loc>https://example.loc/lv/par-mums<") {
+		t.Fatal("Urls must be sorted by language", sitemap)
+	}
+	for _, s := range []string{"secret", "hidden", "moved", "<priority>2", "sometimes"} {
+		if strings.Contains(sitemap, "<loc>https://example.loc/en/"+s) || strings.Contains(sitemap, s+"<") {
+			t.Fatal("Must not be in sitemap:", s, sitemap)
+		}
+	}
+	if files, _ := filepath.Glob(app.PublicPath + "/sitemap-*"); len(files) != 0 {
+		t.Fatal("Language sitemaps only if urls don't fit in one file", files)
+	}
+}
+
+func Test_SitemapGzip(t *testing.T) {
+	app := tSitemapSite(t, "SitemapGzip: Yes\n")
+
+	plain, _ := ioutil.ReadFile(app.PublicPath + "/sitemap.xml")
+	f, err := os.Open(app.PublicPath + "/sitemap.xml.gz")
+	if err != nil {
+		t.Fatal("Gzipped sitemap must be written", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, _ := ioutil.ReadAll(zr)
+	if s := string(buf); s != string(plain) || !strings.Contains(s, "<urlset") {
+		t.Fatal("sitemap.xml.gz must have same urls as sitemap.xml", s)
+	}
+
+	// Not gzipped when turned off
+	app.isSitemapGzip = false
+	app.LoadContent()
+	if _, err := os.Stat(app.PublicPath + "/sitemap.xml.gz"); err == nil {
+		t.Fatal("Old gzipped sitemap must be removed")
+	}
+}
+
+func Test_SitemapParts(t *testing.T) {
+	defer func(n int) { maxSitemapURLs = n }(maxSitemapURLs)
+	maxSitemapURLs = 2
+
+	app := tSitemapSite(t, "SitemapGzip: Yes\n")
+
+	buf, _ := ioutil.ReadFile(app.PublicPath + "/sitemap.xml")
+	index := string(buf)
+	if !strings.Contains(index, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`) ||
+		strings.Index(index, "sitemap-en-1.xml.gz") > strings.Index(index, "sitemap-lv.xml.gz") {
+		t.Fatal("sitemap.xml must be index of language sitemaps", index)
+	}
+	for _, fname := range []string{"sitemap-en-1.xml.gz", "sitemap-en-2.xml.gz", "sitemap-lv.xml.gz"} {
+		if !strings.Contains(index, "<loc>https://example.loc/"+fname+"</loc>") {
+			t.Fatal("Index must contain", fname, index)
+		}
+	}
+
+	f, err := os.Open(app.PublicPath + "/sitemap-en-2.xml.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, _ = ioutil.ReadAll(zr)
+	if s := string(buf); strings.Count(s, "<url>") != 1 || !strings.Contains(s, "/en/contact<") {
+		t.Fatal("Last part must have rest of urls", s)
+	}
+
+	f2, err := os.Open(app.PublicPath + "/sitemap-lv.xml.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f2.Close()
+	zr, err = gzip.NewReader(f2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, _ = ioutil.ReadAll(zr)
+	if lv := string(buf); !strings.Contains(lv, "<loc>https://example.loc/lv/par-mums</loc>") ||
+		!strings.Contains(lv, `hreflang="en" href="https://example.loc/en/about"`) || strings.Contains(lv, "/en/contact<") {
+		t.Fatal("sitemap-lv.xml.gz must have pages of language with translations", lv)
+	}
+
+	// Parts from previous load are removed
+	maxSitemapURLs = 10
+	app.LoadContent()
+	if files, _ := filepath.Glob(app.PublicPath + "/sitemap-*"); len(files) != 0 {
+		t.Fatal("Old sitemap parts must be removed", files)
+	}
+}
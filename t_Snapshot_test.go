@@ -0,0 +1,81 @@
+package mango
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func Test_Snapshot(t *testing.T) {
+	app, _ := NewApplication()
+	s := app.Snapshot()
+	count := s.PageCount()
+
+	// Readers never see half loaded content
+	var wg sync.WaitGroup
+	chStop := make(chan bool)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-chStop:
+					return
+				default:
+				}
+
+				s := app.Snapshot()
+				if s.Page("hello") == nil || s.PageCount() != count || len(s.Pages) != 2 {
+					t.Error("Incomplete snapshot")
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		app.LoadContent()
+	}
+	close(chStop)
+	wg.Wait()
+
+	// Old snapshot stays as it was
+	if app.Snapshot() == s || s.PageCount() != count {
+		t.Fatal("Reload must create new snapshot and leave old one untouched")
+	}
+
+	// Pages look up pages in their own snapshot
+	hello := s.Page("hello")
+	if tPage(hello, "news") != s.Page("news") || hello.Snapshot() != s {
+		t.Fatal("Old page must look up pages in old snapshot")
+	}
+	if tPage(app.Page("hello"), "news") != app.Page("news") {
+		t.Fatal("New page must look up pages in new snapshot")
+	}
+
+	// Partial reload creates new snapshot too
+	fpath := app.ContentPath + "/1_en/top-menu/2_News/Snapshot.md"
+	defer os.Remove(fpath)
+	ioutil.WriteFile(fpath, nil, 0644)
+
+	s = app.Snapshot()
+	menu := s.Page("news")
+	menuPages := len(menu.Pages)
+	app.ReloadPath(fpath)
+	if app.Snapshot() == s || s.Page("snapshot") != nil || app.Page("snapshot") == nil {
+		t.Fatal("Partial reload must be swapped in as new snapshot")
+	}
+
+	// Pages of old snapshot are not changed
+	hello = s.Page("hello")
+	if hello == app.Page("hello") || hello.Snapshot() != s || tPage(hello, "snapshot") != nil {
+		t.Fatal("Old page must stay in old snapshot")
+	}
+	if s.Page("news") != menu || len(menu.Pages) != menuPages || len(app.Page("news").Pages) != menuPages+1 {
+		t.Fatal("Old parent must keep old sub-pages")
+	}
+	if tPage(app.Page("hello"), "snapshot") == nil || app.Page("snapshot").Parent != app.Page("news") {
+		t.Fatal("New pages must look up pages in new snapshot")
+	}
+}
@@ -0,0 +1,57 @@
+package mango
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_Summary(t *testing.T) {
+	long := strings.Repeat("word ", 450)
+	_, app := tSite(t, map[string]string{
+		"/.mango": `Collections: Tags`,
+		"/templates/layout.tmpl": `{{ define "layout" }}{{ range .Pages }}` +
+			`[{{ Summary . }}|{{ Description . }}|{{ WordCount . }}|{{ ReadingTime . }}]{{ end }}{{ end }}`,
+		"/content/en/site/news/.dir":      "Title: News",
+		"/content/en/site/news/1_More.md": "# Intro\n\nFirst **bold** part.\n\n<!--more-->\n\nRest of text.\n",
+		"/content/en/site/news/2_Long.md": "SummaryWords: 5\n+++\nOne 2 < 3 & four five six seven\n\n" + long,
+		"/content/en/site/news/3_Own.md":  "Summary: Own summary\nDescription: Own description\n+++\nText",
+	})
+
+	more := app.Page("more")
+	if more.Get("Summary") != "Intro First bold part." || more.WordCount() != 7 || more.ReadingTime() != 1 {
+		t.Fatal("Summary must be text before marker", more.Params())
+	}
+	if s := string(more.Summary()); s != "<h1 id=\"intro\">Intro</h1>\n\n<p>First <strong>bold</strong> part.</p>" {
+		t.Fatal("Summary() must be HTML before marker", s)
+	}
+
+	long2 := app.Page("long")
+	if long2.Get("Summary") != "One 2 < 3 &…" || long2.Get("WordCount") != "459" || long2.Get("ReadingTime") != "3" {
+		t.Fatal("Summary must be first words", long2.Params())
+	}
+	if s := string(long2.Summary()); s != "One 2 &lt; 3 &amp;…" {
+		t.Fatal("Summary() must be escaped text", s)
+	}
+
+	own := app.Page("own")
+	if own.Get("Summary") != "Own summary" || own.Description() != "Own description" {
+		t.Fatal("Params from file must stay", own.Params())
+	}
+
+	// Description cut at word end
+	long2.Set("Summary", strings.Repeat("abcdefghi ", 20))
+	if d := long2.Description(); d != strings.TrimSpace(strings.Repeat("abcdefghi ", 16))+"…" {
+		t.Fatal("Description must be cut from summary", d)
+	}
+
+	srv := NewAppServer(app, 0)
+	ts := httptest.NewServer(srv.preStart())
+	defer ts.Close()
+
+	_, body := tHTTPGet(t, ts.URL+"/en/news")
+	if !strings.Contains(body, "[Own summary|Own description|1|1]") ||
+		!strings.Contains(body, "|Intro First bold part.|7|1]") {
+		t.Fatal("Template functions must give summary, description and counts", body)
+	}
+}
@@ -0,0 +1,56 @@
+package mango
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_TOC(t *testing.T) {
+	_, app := tSite(t, map[string]string{
+		"/.mango":                `Collections: Tags`,
+		"/templates/layout.tmpl": `{{ define "layout" }}{{ TOC . }}|{{ TOC . 2 2 }}|{{ Content . }}{{ end }}`,
+		"/content/en/news/Guide.md": "IsAnchors: Yes\nTOCMinLevel: 2\n+++\n# Guide\n\n" +
+			"## Install *now*\n\n### On Linux\n\n### On Mac\n\n## Fish & chips\n\n#### Deep\n",
+		"/content/en/news/Plain.md": "# Plain\n\nText\n",
+	})
+
+	page := app.Page("guide")
+	tree := page.Headings()
+	if len(tree) != 1 || tree[0].Title != "Guide" || len(tree[0].Children) != 2 {
+		t.Fatal("Heading tree must have one root with 2 children", tree)
+	}
+	if h := tree[0].Children[0]; h.Title != "Install now" || h.ID != "install-now" || len(h.Children) != 2 {
+		t.Fatal("Heading must have plain title, id and children", h)
+	}
+	if h := tree[0].Children[1]; len(h.Children) != 1 || h.Children[0].Level != 4 {
+		t.Fatal("Skipped level must go under nearest heading", h)
+	}
+
+	srv := NewAppServer(app, 0)
+	ts := httptest.NewServer(srv.preStart())
+	defer ts.Close()
+
+	_, body := tHTTPGet(t, ts.URL+"/en/guide")
+	parts := strings.Split(body, "|")
+	if len(parts) != 3 {
+		t.Fatal("Unexpected output", body)
+	}
+	expected := `<nav class="toc"><ul>` +
+		`<li><a href="#install-now">Install now</a><ul><li><a href="#on-linux">On Linux</a></li><li><a href="#on-mac">On Mac</a></li></ul></li>` +
+		`<li><a href="#fish-chips">Fish &amp; chips</a><ul><li><a href="#deep">Deep</a></li></ul></li>` +
+		`</ul></nav>`
+	if parts[0] != expected {
+		t.Fatalf("TOC from TOCMinLevel expected\n%s\nfound\n%s", expected, parts[0])
+	}
+	if parts[1] != `<nav class="toc"><ul><li><a href="#install-now">Install now</a></li><li><a href="#fish-chips">Fish &amp; chips</a></li></ul></nav>` {
+		t.Fatal("TOC must have only given levels", parts[1])
+	}
+	if !strings.Contains(parts[2], `<h3 id="on-mac">On Mac <a class="anchor" href="#on-mac" aria-hidden="true">#</a></h3>`) {
+		t.Fatal("Headings must have anchors", parts[2])
+	}
+
+	if s := string(app.Page("plain").Content()); strings.Contains(s, "anchor") {
+		t.Fatal("Anchors only with IsAnchors param", s)
+	}
+}
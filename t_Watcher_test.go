@@ -0,0 +1,119 @@
+package mango
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Watcher(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		app, _ := NewApplication()
+		app.watchDelay = 50 * time.Millisecond
+		app.watchInterval = 50 * time.Millisecond
+
+		reloaded := make(chan bool, 10)
+		app.OnReload = func(app *Application) {
+			reloaded <- true
+		}
+
+		w, err := app.Watch(poll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.IsPolling() != poll {
+			t.Fatal("Incorrect watch mode. Polling:", w.IsPolling())
+		}
+
+		// Polling compares modification times
+		// so make sure new file is noticed
+		time.Sleep(60 * time.Millisecond)
+
+		// Burst of changes results in one reload
+		fpath := "test-files/content/1_en/top-menu/Watched.md"
+		for i := 0; i < 5; i++ {
+			ioutil.WriteFile(fpath, []byte("# Watched"), 0644)
+		}
+
+		select {
+		case <-reloaded:
+		case <-time.After(3 * time.Second):
+			os.Remove(fpath)
+			w.Stop()
+			t.Fatal("Content not reloaded. Polling:", poll)
+		}
+
+		// New page is already in place when OnReload fires
+		if app.Page("watched") == nil {
+			t.Fatal("New page must be found after reload. Polling:", poll)
+		}
+
+		os.Remove(fpath)
+		<-reloaded
+		if app.Page("watched") != nil {
+			t.Fatal("Removed page must not be found after reload. Polling:", poll)
+		}
+
+		w.Stop()
+		w.Stop() // second stop does nothing
+
+		select {
+		case <-reloaded:
+			t.Fatal("Burst of changes must result in one reload. Polling:", poll)
+		default:
+		}
+	}
+}
+
+func Test_WatcherError(t *testing.T) {
+	// No content to watch
+	dir := tWriteSite(t, map[string]string{"/.mango": "Watch: Yes\nContentPath: missing"})
+
+	app, err := NewApplicationAt(dir)
+	if err == nil || app == nil {
+		t.Fatal("Watch error must be returned with app", err)
+	}
+	if app.Watcher() != nil {
+		t.Fatal("Watcher must not be set")
+	}
+}
+
+func Test_WatcherTemplates(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		dir, app := tSite(t, map[string]string{
+			"/.mango":                 "CacheSize: 1MB\n",
+			"/templates/layout.tmpl":  `{{ define "layout" }}OLD {{ Content . }}{{ end }}`,
+			"/content/en/news/Dog.md": "Woof",
+		})
+		app.watchDelay = 50 * time.Millisecond
+		app.watchInterval = 50 * time.Millisecond
+		srv := NewAppServer(app, 0)
+		ts := httptest.NewServer(srv.preStart())
+
+		if _, body := tHTTPGet(t, ts.URL+"/en/dog"); !strings.HasPrefix(body, "OLD") {
+			t.Fatal("Incorrect page", body)
+		}
+
+		w, err := app.Watch(poll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(60 * time.Millisecond)
+		ioutil.WriteFile(dir+"/templates/layout.tmpl", []byte(`{{ define "layout" }}NEW {{ Content . }}{{ end }}`), 0644)
+
+		// Cached page is dropped too
+		body := ""
+		for i := 0; i < 60 && !strings.HasPrefix(body, "NEW"); i++ {
+			time.Sleep(50 * time.Millisecond)
+			_, body = tHTTPGet(t, ts.URL+"/en/dog")
+		}
+		w.Stop()
+		ts.Close()
+		if !strings.HasPrefix(body, "NEW") {
+			t.Fatal("Changed template must be used. Polling:", poll, body)
+		}
+	}
+}
@@ -17,14 +17,14 @@ func Benchmark_App_Parallel(b *testing.B) {
 			app.Page("hello")
 
 			// Search
-			app.Search("en", "oc") // hOCkey, sOCcer
+			app.Search("en", "cats")
 
 			// PageMap
 			p := &Page{}
 			p.Set("Slug", "slug-x") // slug must be set for  slugPages
-			app.slugPages.Add("slug-x", p)
+			app.Snapshot().slugPages.Add("slug-x", p)
 			app.PageCount()
-			app.slugPages.Remove("slug-x")
+			app.Snapshot().slugPages.Remove("slug-x")
 
 			// Params
 			p.Set("Custom", "hi")